@@ -48,11 +48,11 @@ func GenerateAscii(img image.Image, asciiChars string, lineHeight, fontSize int,
 	newFontSize, newLineHeight := resizeAscii(lineHeight, fontSize)
 	bounds := img.Bounds()
 
-	asciiImage := make([][]AsciiImage, bounds.Max.Y)
+	asciiImage := make([][]AsciiImage, bounds.Dy())
 
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 
-		line := make([]AsciiImage, bounds.Max.X)
+		line := make([]AsciiImage, bounds.Dx())
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 			px := img.At(x, y)
 			gr := color.GrayModel.Convert(px)
@@ -75,9 +75,9 @@ func GenerateAscii(img image.Image, asciiChars string, lineHeight, fontSize int,
 				jsImgInfo.Color = "#fff"
 			}
 
-			line[x] = jsImgInfo
+			line[x-bounds.Min.X] = jsImgInfo
 		}
-		asciiImage[y] = line
+		asciiImage[y-bounds.Min.Y] = line
 	}
 
 	return asciiImage
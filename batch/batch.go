@@ -0,0 +1,433 @@
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BrunoPoiano/imgeffects/effects"
+	"github.com/BrunoPoiano/imgeffects/imageio"
+)
+
+// Options configures a batch run.
+//
+// Fields:
+//   - Pipeline: The effects applied to every image
+//   - Input: A directory or a glob pattern (e.g. "photos/*.jpg")
+//   - OutputDir: The directory receiving the processed images, created if missing. Its
+//     files are never taken as inputs, and two inputs writing the same output are an error.
+//   - Recursive: When Input is a directory, also process its sub directories
+//   - Format: Output extension ("png", "jpg", "gif"), empty keeps the input extension
+//   - Workers: Number of images processed at the same time (defaults to the number of CPUs).
+//     The pixel work of every image shares the CPUs through utils.ParallelExecution,
+//     so raising Workers mostly overlaps decoding and encoding.
+//   - Force: Process every image even when its output is up to date. An output is up to
+//     date when it is newer than its input and was made by the same effects and parameter
+//     values, as recorded in the manifest written to OutputDir.
+type Options struct {
+	Pipeline  *effects.Pipeline
+	Input     string
+	OutputDir string
+	Recursive bool
+	Format    string
+	Workers   int
+	Force     bool
+}
+
+// Status is the outcome of processing a single file.
+type Status string
+
+const (
+	Processed Status = "processed"
+	Skipped   Status = "skipped"
+	Failed    Status = "failed"
+)
+
+// Result describes the outcome of processing a single file.
+type Result struct {
+	Input    string        `json:"input"`
+	Output   string        `json:"output"`
+	Status   Status        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report summarises a batch run.
+type Report struct {
+	Results   []Result      `json:"results"`
+	Processed int           `json:"processed"`
+	Skipped   int           `json:"skipped"`
+	Failed    int           `json:"failed"`
+	Duration  time.Duration `json:"duration"`
+}
+
+type job struct {
+	input, output string
+}
+
+// ManifestName is the file of OutputDir recording the effects that made each
+// output, so changing the recipe or its parameters reprocesses the images.
+const ManifestName = ".imgeffects-batch.json"
+
+// manifest maps the outputs, relative to OutputDir, to the hash of the
+// pipeline that made them.
+type manifest struct {
+	mu      sync.Mutex
+	Outputs map[string]string `json:"outputs"`
+}
+
+// loadManifest reads the manifest of dir. A missing or unreadable manifest is
+// empty, so every output is processed again.
+func loadManifest(dir string) *manifest {
+	m := &manifest{}
+	if data, err := os.ReadFile(filepath.Join(dir, ManifestName)); err == nil {
+		_ = json.Unmarshal(data, m)
+	}
+	if m.Outputs == nil {
+		m.Outputs = map[string]string{}
+	}
+	return m
+}
+
+func (m *manifest) key(dir, output string) string {
+	rel, err := filepath.Rel(dir, output)
+	if err != nil {
+		return output
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (m *manifest) get(key string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Outputs[key]
+}
+
+func (m *manifest) set(key, hash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hash == "" {
+		delete(m.Outputs, key)
+		return
+	}
+	m.Outputs[key] = hash
+}
+
+func (m *manifest) save(dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestName), data, 0o644)
+}
+
+// pipelineHash identifies the effects of a pipeline with all their parameter values,
+// defaults included.
+func pipelineHash(effectList []effects.Effect) (string, error) {
+	type step struct {
+		Effect string         `json:"effect"`
+		Params effects.Values `json:"params"`
+	}
+	steps := make([]step, len(effectList))
+	for i, effect := range effectList {
+		steps[i] = step{Effect: effect.Name(), Params: effect.Values()}
+	}
+	data, err := json.Marshal(steps)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Run applies the pipeline to every image matched by opts.Input using a bounded
+// worker pool. Outputs newer than their input and made by the same effects are
+// skipped unless opts.Force is set.
+// Failures of single files are recorded in the report and do not stop the run.
+//
+// Parameters:
+//   - opts: The batch options
+//
+// Returns:
+//   - *Report: The outcome of every file, ordered by input path
+//   - error: When the options are invalid or the inputs cannot be listed
+func Run(opts Options) (*Report, error) {
+	return RunContext(context.Background(), opts)
+}
+
+// RunContext works like Run but stops as soon as ctx is cancelled. Files that
+// were not processed are reported as failed with the context error.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - opts: The batch options
+//
+// Returns:
+//   - *Report: The outcome of every file, ordered by input path
+//   - error: When the options are invalid, the inputs cannot be listed or ctx was cancelled
+func RunContext(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Pipeline == nil {
+		return nil, errors.New("batch: nil pipeline")
+	}
+	if opts.OutputDir == "" {
+		return nil, errors.New("batch: empty output directory")
+	}
+	effectList, err := opts.Pipeline.Effects()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := pipelineHash(effectList)
+	if err != nil {
+		return nil, fmt.Errorf("batch: %w", err)
+	}
+
+	jobs, err := collect(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	start := time.Now()
+	state := loadManifest(opts.OutputDir)
+	results := make([]Result, len(jobs))
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				results[index] = process(ctx, opts, jobs[index], state, hash)
+			}
+		}()
+	}
+
+	for i := range jobs {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			results[i] = Result{Input: jobs[i].input, Output: jobs[i].output, Status: Failed, Error: ctx.Err().Error()}
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := state.save(opts.OutputDir); err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("batch: %w", err)
+	}
+
+	report := &Report{Results: results, Duration: time.Since(start)}
+	for _, result := range results {
+		switch result.Status {
+		case Processed:
+			report.Processed++
+		case Skipped:
+			report.Skipped++
+		case Failed:
+			report.Failed++
+		}
+	}
+
+	return report, ctx.Err()
+}
+
+func process(ctx context.Context, opts Options, j job, state *manifest, hash string) Result {
+	start := time.Now()
+	result := Result{Input: j.input, Output: j.output}
+	key := state.key(opts.OutputDir, j.output)
+
+	if !opts.Force && state.get(key) == hash && upToDate(j.input, j.output) {
+		result.Status = Skipped
+		return result
+	}
+
+	err := func() error {
+		img, _, err := imageio.Load(j.input)
+		if err != nil {
+			return err
+		}
+
+		img, err = opts.Pipeline.RunContext(ctx, img, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(j.output), 0o755); err != nil {
+			return err
+		}
+		return imageio.Save(j.output, img)
+	}()
+
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Status = Failed
+		result.Error = err.Error()
+		state.set(key, "")
+	} else {
+		result.Status = Processed
+		state.set(key, hash)
+	}
+
+	return result
+}
+
+// upToDate reports whether output exists and is not older than input.
+func upToDate(input, output string) bool {
+	in, err := os.Stat(input)
+	if err != nil {
+		return false
+	}
+	out, err := os.Stat(output)
+	if err != nil {
+		return false
+	}
+	return !out.ModTime().Before(in.ModTime())
+}
+
+// collect lists the input images and their output paths, sorted by input path.
+// The files of OutputDir are left out, so the outputs of a previous run are
+// not processed again, and two inputs writing the same output are an error.
+func collect(opts Options) ([]job, error) {
+	var jobs []job
+	outputDir, err := filepath.Abs(opts.OutputDir)
+	if err != nil {
+		return nil, fmt.Errorf("batch: %w", err)
+	}
+
+	info, err := os.Stat(opts.Input)
+	if err == nil && info.IsDir() {
+		if within(outputDir, opts.Input) {
+			return nil, fmt.Errorf("batch: the input directory %q is inside the output directory %q", opts.Input, opts.OutputDir)
+		}
+		err = filepath.WalkDir(opts.Input, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != opts.Input && (!opts.Recursive || within(outputDir, path)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !supported(path) || within(outputDir, path) {
+				return nil
+			}
+
+			rel, err := filepath.Rel(opts.Input, path)
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, job{input: path, output: outputPath(opts, rel)})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("batch: %w", err)
+		}
+	} else {
+		matches, err := filepath.Glob(opts.Input)
+		if err != nil {
+			return nil, fmt.Errorf("batch: %w", err)
+		}
+		for _, path := range matches {
+			if info, err := os.Stat(path); err != nil || info.IsDir() || !supported(path) || within(outputDir, path) {
+				continue
+			}
+			jobs = append(jobs, job{input: path, output: outputPath(opts, filepath.Base(path))})
+		}
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("batch: no images found in %q", opts.Input)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].input < jobs[j].input })
+
+	// e.g. a/x.png and b/x.png matched by a glob, or x.png and x.jpg with a Format.
+	inputs := make(map[string]string, len(jobs))
+	for _, j := range jobs {
+		if other, ok := inputs[j.output]; ok {
+			return nil, fmt.Errorf("batch: %q and %q would both be written to %q", other, j.input, j.output)
+		}
+		inputs[j.output] = j.input
+	}
+	return jobs, nil
+}
+
+// within reports whether path is dir or one of its descendants, dir being an
+// absolute path.
+func within(dir, path string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func supported(path string) bool {
+	_, err := imageio.FormatFromPath(path)
+	return err == nil
+}
+
+func outputPath(opts Options, rel string) string {
+	if opts.Format != "" {
+		rel = strings.TrimSuffix(rel, filepath.Ext(rel)) + "." + strings.TrimPrefix(opts.Format, ".")
+	}
+	return filepath.Join(opts.OutputDir, rel)
+}
+
+// WriteJSON writes the report as indented JSON.
+//
+// Parameters:
+//   - w: The destination writer
+//
+// Returns:
+//   - error
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteSummary writes a human readable summary listing the failed files.
+//
+// Parameters:
+//   - w: The destination writer
+//
+// Returns:
+//   - error
+func (r *Report) WriteSummary(w io.Writer) error {
+	for _, result := range r.Results {
+		if result.Status == Failed {
+			if _, err := fmt.Fprintf(w, "failed %s: %s\n", result.Input, result.Error); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d processed, %d skipped, %d failed in %s\n",
+		r.Processed, r.Skipped, r.Failed, r.Duration.Round(time.Millisecond))
+	return err
+}
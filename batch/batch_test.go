@@ -0,0 +1,185 @@
+package batch_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/batch"
+	"github.com/BrunoPoiano/imgeffects/effects"
+	"github.com/BrunoPoiano/imgeffects/imageio"
+)
+
+// writeImages saves a small image at each path, relative to dir.
+func writeImages(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	img.Set(1, 1, color.RGBA{200, 100, 50, 255})
+	for _, path := range paths {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := imageio.Save(path, img); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func gamma(value float64) *effects.Pipeline {
+	return effects.NewPipeline().Add("gamma-correction", effects.Values{"gamma": value})
+}
+
+func run(t *testing.T, opts batch.Options) *batch.Report {
+	t.Helper()
+	report, err := batch.Run(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func checkCounts(t *testing.T, report *batch.Report, processed, skipped, failed int) {
+	t.Helper()
+	if report.Processed != processed || report.Skipped != skipped || report.Failed != failed {
+		t.Fatalf("got %d processed, %d skipped, %d failed, want %d, %d, %d",
+			report.Processed, report.Skipped, report.Failed, processed, skipped, failed)
+	}
+}
+
+func TestRunSkipsUpToDateOutputs(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, filepath.Join(dir, "in"), "a.png", "b.png")
+	opts := batch.Options{Pipeline: gamma(1.5), Input: filepath.Join(dir, "in"), OutputDir: filepath.Join(dir, "out"), Workers: 2}
+
+	checkCounts(t, run(t, opts), 2, 0, 0)
+	checkCounts(t, run(t, opts), 0, 2, 0)
+
+	opts.Force = true
+	checkCounts(t, run(t, opts), 2, 0, 0)
+}
+
+func TestRunReprocessesWhenThePipelineChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, filepath.Join(dir, "in"), "a.png", "b.png")
+	opts := batch.Options{Pipeline: gamma(1.5), Input: filepath.Join(dir, "in"), OutputDir: filepath.Join(dir, "out")}
+	checkCounts(t, run(t, opts), 2, 0, 0)
+
+	opts.Pipeline = gamma(2)
+	checkCounts(t, run(t, opts), 2, 0, 0)
+
+	opts.Pipeline = gamma(2).Add("invert", nil)
+	checkCounts(t, run(t, opts), 2, 0, 0)
+	checkCounts(t, run(t, opts), 0, 2, 0)
+
+	// A manifest entry removed by hand reprocesses its output only.
+	manifest := filepath.Join(dir, "out", batch.ManifestName)
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content struct {
+		Outputs map[string]string `json:"outputs"`
+	}
+	if err := json.Unmarshal(data, &content); err != nil {
+		t.Fatal(err)
+	}
+	if len(content.Outputs) != 2 {
+		t.Fatalf("manifest holds %d outputs, want 2", len(content.Outputs))
+	}
+	delete(content.Outputs, "a.png")
+	if data, err = json.Marshal(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manifest, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checkCounts(t, run(t, opts), 1, 1, 0)
+}
+
+func TestRunRejectsOutputCollisions(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir, "a/x.png", "b/x.png", "c/y.png", "c/y.jpg")
+
+	for name, opts := range map[string]batch.Options{
+		"same base name": {Input: filepath.Join(dir, "*", "x.png")},
+		"same format":    {Input: filepath.Join(dir, "c"), Format: "gif"},
+	} {
+		opts.Pipeline = gamma(1.5)
+		opts.OutputDir = filepath.Join(dir, "out")
+		if _, err := batch.Run(opts); err == nil || !strings.Contains(err.Error(), "would both be written") {
+			t.Errorf("%s: got %v, want a collision error", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Errorf("the output directory was written despite the collision: %v", err)
+	}
+}
+
+func TestRunSkipsTheOutputDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, dir, "a.png", "sub/b.png")
+	opts := batch.Options{Pipeline: gamma(1.5), Input: dir, OutputDir: filepath.Join(dir, "out"), Recursive: true}
+
+	checkCounts(t, run(t, opts), 2, 0, 0)
+	report := run(t, opts)
+	checkCounts(t, report, 0, 2, 0)
+	for _, result := range report.Results {
+		if strings.HasPrefix(result.Input, opts.OutputDir) {
+			t.Errorf("the output %s was processed as an input", result.Input)
+		}
+	}
+
+	opts.Input, opts.OutputDir = filepath.Join(dir, "sub"), dir
+	if _, err := batch.Run(opts); err == nil {
+		t.Error("no error for an input directory inside the output directory")
+	}
+}
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	writeImages(t, filepath.Join(dir, "in"), "a.png")
+	if err := os.WriteFile(filepath.Join(dir, "in", "broken.png"), []byte("not a png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := batch.Options{Pipeline: gamma(1.5), Input: filepath.Join(dir, "in"), OutputDir: filepath.Join(dir, "out")}
+	report := run(t, opts)
+	checkCounts(t, report, 1, 0, 1)
+
+	if len(report.Results) != 2 || report.Results[0].Status != batch.Processed || report.Results[1].Status != batch.Failed {
+		t.Fatalf("unexpected results %+v", report.Results)
+	}
+	if report.Results[1].Error == "" {
+		t.Error("the failed result has no error")
+	}
+
+	var summary bytes.Buffer
+	if err := report.WriteSummary(&summary); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(summary.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "failed "+filepath.Join(dir, "in", "broken.png")+": ") ||
+		!strings.HasPrefix(lines[1], "1 processed, 0 skipped, 1 failed in ") {
+		t.Errorf("unexpected summary:\n%s", summary.String())
+	}
+
+	var encoded bytes.Buffer
+	if err := report.WriteJSON(&encoded); err != nil {
+		t.Fatal(err)
+	}
+	var decoded batch.Report
+	if err := json.Unmarshal(encoded.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Processed != 1 || decoded.Failed != 1 || len(decoded.Results) != 2 || decoded.Results[1].Status != batch.Failed {
+		t.Errorf("unexpected JSON report %s", encoded.String())
+	}
+
+	// The failed file is not recorded, so the next run tries it again.
+	checkCounts(t, run(t, opts), 0, 1, 1)
+}
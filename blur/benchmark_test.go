@@ -0,0 +1,15 @@
+package blur_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/blur"
+	"github.com/BrunoPoiano/imgeffects/effects/effectstest"
+)
+
+func BenchmarkMedian(b *testing.B) {
+	effectstest.BenchmarkPixelAccess(b, func(img image.Image) image.Image {
+		return blur.Median(img, 5)
+	})
+}
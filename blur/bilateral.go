@@ -0,0 +1,170 @@
+package blur
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// Bilateral smooths an image while keeping its edges sharp. Each pixel is
+// replaced with the average of its neighbours weighted by two Gaussians: one
+// of their distance, as in a Gaussian blur, and one of their colour
+// difference, so neighbours across an edge barely count. It is a common
+// choice for skin smoothing and for flattening textures before a posterize.
+//
+// The filter runs separably, along the rows and then along the columns, with
+// the colour differences always measured on the source image. This costs
+// 4*sigmaSpatial+1 neighbours per pass instead of the whole square and stays
+// close to the full filter, with slight streaks along diagonal edges at large
+// spatial sigmas.
+//
+// Parameters:
+//   - img: The source image to be processed
+//   - sigmaSpatial: The spatial standard deviation in pixels, valid range 0.5-50
+//   - sigmaRange: The colour standard deviation in 8-bit steps, valid range 1-255.
+//     Smaller values keep more edges, large values approach a Gaussian blur.
+//
+// Returns:
+//   - image.Image
+//
+// Note: Values outside the ranges are clamped. The cost per pixel grows linearly with
+// sigmaSpatial.
+func Bilateral(img image.Image, sigmaSpatial, sigmaRange float64) image.Image {
+	newImage, _ := BilateralContext(context.Background(), img, sigmaSpatial, sigmaRange, nil)
+	return newImage
+}
+
+// BilateralContext works like Bilateral but aborts as soon as ctx is
+// cancelled and reports progress. The total reported to progress covers both
+// passes, the image height plus its width.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be processed
+//   - sigmaSpatial: The spatial standard deviation in pixels (0.5-50)
+//   - sigmaRange: The colour standard deviation in 8-bit steps (1-255)
+//   - progress: Optional callback receiving the completed and total rows and columns, may be nil
+//
+// Returns:
+//   - image.Image: The filtered image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func BilateralContext(ctx context.Context, img image.Image, sigmaSpatial, sigmaRange float64, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if bounds.Empty() {
+		return image.NewRGBA64(bounds), nil
+	}
+	sigmaSpatial = utils.ClampFloat64(sigmaSpatial, 0.5, 50)
+	sigmaRange = utils.ClampFloat64(sigmaRange, 1, 255)
+	radius := int(math.Ceil(2 * sigmaSpatial))
+	reader := pixel.NewReader(img)
+
+	// The colour differences are measured on straight values, in 8-bit
+	// steps, with the alpha as a fourth channel. The premultiplied values
+	// are the ones averaged.
+	straight := make([]float32, 4*width*height)
+	values := make([]float32, 4*width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b, a := reader.RGBA(bounds.Min.X+x, bounds.Min.Y+y)
+			i := 4 * (y*width + x)
+			values[i], values[i+1], values[i+2], values[i+3] = float32(r), float32(g), float32(b), float32(a)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
+			straight[i], straight[i+1], straight[i+2], straight[i+3] = float32(r)/257, float32(g)/257, float32(b)/257, float32(a)/257
+		}
+	}
+
+	spatial := make([]float64, 2*radius+1)
+	for d := -radius; d <= radius; d++ {
+		spatial[d+radius] = math.Exp(-float64(d*d) / (2 * sigmaSpatial * sigmaSpatial))
+	}
+	// The range weights by squared colour distance, rounded to whole steps.
+	rangeWeights := make([]float64, 4*255*255+1)
+	for d := range rangeWeights {
+		rangeWeights[d] = math.Exp(-float64(d) / (2 * sigmaRange * sigmaRange))
+	}
+
+	// filterLine filters the n pixels starting at the index first, step
+	// pixels apart, from src into dst.
+	filterLine := func(src, dst []float32, first, step, n int) {
+		for p := range n {
+			centre := straight[4*(first+p*step):][:4]
+			var r, g, b, a, sum float64
+			for d := max(-radius, -p); d <= min(radius, n-1-p); d++ {
+				i := 4 * (first + (p+d)*step)
+				neighbour := straight[i : i+4]
+				var distance float32
+				for c := range 4 {
+					diff := neighbour[c] - centre[c]
+					distance += diff * diff
+				}
+				weight := spatial[d+radius] * rangeWeights[min(int(distance+0.5), len(rangeWeights)-1)]
+				r += float64(src[i]) * weight
+				g += float64(src[i+1]) * weight
+				b += float64(src[i+2]) * weight
+				a += float64(src[i+3]) * weight
+				sum += weight
+			}
+			i := 4 * (first + p*step)
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = float32(r/sum), float32(g/sum), float32(b/sum), float32(a/sum)
+		}
+	}
+
+	var horizontalProgress, verticalProgress utils.ProgressFunc
+	if progress != nil {
+		horizontalProgress = func(done, _ int) { progress(done, height+width) }
+		verticalProgress = func(done, _ int) { progress(height+done, height+width) }
+	}
+
+	horizontal := make([]float32, 4*width*height)
+	horizontalFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			filterLine(values, horizontal, (y-bounds.Min.Y)*width, 1, width)
+		}
+	}
+	_, err := utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{Image: img, Function: horizontalFunc, Progress: horizontalProgress, NoOutput: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// The columns are split between the workers, values receiving the result.
+	verticalFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for x := start; x < end; x++ {
+			if ctx.Err() != nil {
+				return
+			}
+			filterLine(horizontal, values, x, width, height)
+		}
+	}
+	_, err = utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{
+		Image:    img,
+		Function: verticalFunc,
+		EndSize:  width,
+		Progress: verticalProgress,
+		NoOutput: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	newImage := image.NewRGBA64(bounds)
+	for y := range height {
+		for x := range width {
+			p := values[4*(y*width+x):][:4]
+			alpha := min(float64(p[3]), 0xffff)
+			channel := func(v float32) uint16 { return uint16(min(float64(v), alpha) + 0.5) }
+			newImage.SetRGBA64(bounds.Min.X+x, bounds.Min.Y+y, color.RGBA64{channel(p[0]), channel(p[1]), channel(p[2]), uint16(alpha + 0.5)})
+		}
+	}
+	return newImage, nil
+}
@@ -0,0 +1,324 @@
+package blur
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/resize"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// Aperture is the shape of the lens opening, given to the out of focus
+// highlights by Bokeh.
+type Aperture string
+
+const (
+	Disk    Aperture = "disk"
+	Hexagon Aperture = "hexagon"
+)
+
+// Apertures returns every built-in aperture.
+//
+// Returns:
+//   - []Aperture
+func Apertures() []Aperture {
+	return []Aperture{Disk, Hexagon}
+}
+
+// ParseAperture returns the Aperture named s.
+//
+// Parameters:
+//   - s: The name of the aperture (case-sensitive)
+//
+// Returns:
+//   - Aperture
+//   - error: A *utils.ValidationError listing the supported apertures when s is unknown
+func ParseAperture(s string) (Aperture, error) {
+	aperture := Aperture(s)
+	if err := utils.ValidateOption("aperture", aperture, Apertures()); err != nil {
+		return "", err
+	}
+	return aperture, nil
+}
+
+// BokehOptions configures Bokeh.
+//
+// Fields:
+//   - Radius: The radius of the aperture in pixels (0-200), 0 returns a copy
+//   - Aperture: The shape of the aperture, empty or unknown means Disk
+//   - Shape: A custom aperture replacing Aperture, its luminance times its alpha giving
+//     the weights. It is stretched to the square of side 2*Radius+1.
+//   - Rotation: The clockwise rotation of the hexagon in degrees
+//   - Threshold: The luminance (0-1) above which the highlights are boosted
+//   - Boost: The extra brightness of the highlights, 0 disables the boost. A pixel at full
+//     luminance is multiplied by 1+Boost before the blur, so it blooms into the aperture shape.
+type BokehOptions struct {
+	Radius    int
+	Aperture  Aperture
+	Shape     image.Image
+	Rotation  float64
+	Threshold float64
+	Boost     float64
+}
+
+// apertureKernel returns the (2*radius+1)^2 weights of the aperture described
+// by opts, normalised to a sum of 1. The edges of the built-in shapes are
+// antialiased with 4x4 samples per pixel.
+func apertureKernel(opts BokehOptions, radius int) []float64 {
+	size := 2*radius + 1
+	kernel := make([]float64, size*size)
+
+	if opts.Shape != nil && !opts.Shape.Bounds().Empty() {
+		shape := resize.Resample(opts.Shape, size, size, resize.Bilinear)
+		reader := pixel.NewReader(shape)
+		bounds := shape.Bounds()
+		for y := range size {
+			for x := range size {
+				// The premultiplied luminance is the straight one times alpha.
+				r, g, b, _ := reader.RGBA(bounds.Min.X+x, bounds.Min.Y+y)
+				kernel[y*size+x] = 0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)
+			}
+		}
+	} else {
+		sin, cos := math.Sincos(-opts.Rotation * math.Pi / 180)
+		limit := float64(radius) + 0.5
+		inside := func(x, y float64) bool {
+			if opts.Aperture == Hexagon {
+				// A regular hexagon with two vertices on the horizontal axis.
+				x, y = math.Abs(x*cos-y*sin), math.Abs(x*sin+y*cos)
+				return y <= limit*math.Sqrt(3)/2 && math.Sqrt(3)*x+y <= math.Sqrt(3)*limit
+			}
+			return x*x+y*y <= limit*limit
+		}
+		for y := range size {
+			for x := range size {
+				covered := 0
+				for sy := range 4 {
+					for sx := range 4 {
+						px := float64(x-radius) + (float64(sx)+0.5)/4 - 0.5
+						py := float64(y-radius) + (float64(sy)+0.5)/4 - 0.5
+						if inside(px, py) {
+							covered++
+						}
+					}
+				}
+				kernel[y*size+x] = float64(covered)
+			}
+		}
+	}
+
+	var sum float64
+	for _, w := range kernel {
+		sum += w
+	}
+	if sum == 0 {
+		// An empty custom shape keeps the image sharp.
+		kernel[radius*size+radius], sum = 1, 1
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// Bokeh blurs an image like an out of focus lens: each pixel is spread over
+// the shape of the aperture, so bright spots turn into discs or hexagons
+// instead of the soft blobs of a Gaussian blur. The highlights above
+// Threshold can be boosted to bloom into clearly visible shapes.
+// The convolution runs on tiles with fast Fourier transforms, so its cost
+// per pixel grows only with the logarithm of the radius.
+//
+// Parameters:
+//   - img: The source image to be blurred
+//   - opts: The aperture and highlight options
+//
+// Returns:
+//   - image.Image: A new image with the blur effect applied
+//
+// Note: The values outside the ranges are clamped and the pixels beyond the edges repeat
+// the edge pixels.
+//
+// Example:
+//
+//	background := blur.Bokeh(img, blur.BokehOptions{Radius: 24, Aperture: blur.Hexagon, Threshold: 0.8, Boost: 3})
+func Bokeh(img image.Image, opts BokehOptions) image.Image {
+	newImage, _ := BokehContext(context.Background(), img, opts, nil)
+	return newImage
+}
+
+// BokehContext works like Bokeh but aborts as soon as ctx is cancelled and
+// reports progress. The image is processed in bands of tiles, and the total
+// reported to progress is the number of bands.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be blurred
+//   - opts: The aperture and highlight options
+//   - progress: Optional callback receiving the completed and total bands, may be nil
+//
+// Returns:
+//   - image.Image: The blurred image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func BokehContext(ctx context.Context, img image.Image, opts BokehOptions, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	radius := utils.ClampGeneric(opts.Radius, 0, 200)
+	threshold := utils.ClampFloat64(opts.Threshold, 0, 1)
+	boost := max(opts.Boost, 0)
+	newImage := image.NewRGBA64(bounds)
+	if radius == 0 {
+		draw.Draw(newImage, bounds, img, bounds.Min, draw.Src)
+		return newImage, nil
+	}
+	if width == 0 || height == 0 {
+		return newImage, nil
+	}
+
+	// The premultiplied channels in [0, 1], the highlights boosted.
+	reader := pixel.NewReader(img)
+	values := make([][4]float64, width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b, a := reader.RGBA(bounds.Min.X+x, bounds.Min.Y+y)
+			v := [4]float64{float64(r) / 0xffff, float64(g) / 0xffff, float64(b) / 0xffff, float64(a) / 0xffff}
+			if boost > 0 && threshold < 1 && v[3] > 0 {
+				luminance := (0.2126*v[0] + 0.7152*v[1] + 0.0722*v[2]) / v[3]
+				if luminance > threshold {
+					gain := 1 + boost*(luminance-threshold)/(1-threshold)
+					v[0], v[1], v[2] = v[0]*gain, v[1]*gain, v[2]*gain
+				}
+			}
+			values[y*width+x] = v
+		}
+	}
+
+	// Overlap-save: each tile of n x n samples yields the (n-2*radius)^2
+	// pixels whose whole window lies inside it.
+	n := 64
+	for n-2*radius < max(n/2, 1) {
+		n *= 2
+	}
+	tile := n - 2*radius
+
+	kernel := apertureKernel(opts, radius)
+	size := 2*radius + 1
+	spectrum := make([]complex128, n*n)
+	for y := range size {
+		for x := range size {
+			// Centred on the origin, wrapping around.
+			i := ((y-radius+n)%n)*n + (x-radius+n)%n
+			spectrum[i] = complex(kernel[y*size+x], 0)
+		}
+	}
+	fft2(spectrum, n, false)
+
+	bands := (height + tile - 1) / tile
+	bokehFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		// The kernel is real, so two channels share each transform, one in
+		// the real part and one in the imaginary part.
+		redGreen := make([]complex128, n*n)
+		blueAlpha := make([]complex128, n*n)
+		for band := start; band < end; band++ {
+			top := band * tile
+			for left := 0; left < width; left += tile {
+				if ctx.Err() != nil {
+					return
+				}
+				for y := range n {
+					sy := utils.ClampGeneric(top+y-radius, 0, height-1)
+					for x := range n {
+						sx := utils.ClampGeneric(left+x-radius, 0, width-1)
+						v := values[sy*width+sx]
+						redGreen[y*n+x] = complex(v[0], v[1])
+						blueAlpha[y*n+x] = complex(v[2], v[3])
+					}
+				}
+				fft2(redGreen, n, false)
+				fft2(blueAlpha, n, false)
+				for i, k := range spectrum {
+					redGreen[i] *= k
+					blueAlpha[i] *= k
+				}
+				fft2(redGreen, n, true)
+				fft2(blueAlpha, n, true)
+
+				for y := 0; y < tile && top+y < height; y++ {
+					for x := 0; x < tile && left+x < width; x++ {
+						i := (y+radius)*n + x + radius
+						alpha := utils.ClampFloat64(imag(blueAlpha[i]), 0, 1)
+						channel := func(v float64) uint16 { return uint16(utils.ClampFloat64(v, 0, alpha)*0xffff + 0.5) }
+						newImage.SetRGBA64(bounds.Min.X+left+x, bounds.Min.Y+top+y, color.RGBA64{
+							channel(real(redGreen[i])),
+							channel(imag(redGreen[i])),
+							channel(real(blueAlpha[i])),
+							uint16(alpha*0xffff + 0.5),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	_, err := utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{
+		Image:    img,
+		Function: bokehFunc,
+		EndSize:  bands,
+		Progress: progress,
+		NoOutput: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newImage, nil
+}
+
+// BokehStrict works like Bokeh but returns an error for an unknown aperture
+// or options out of their ranges. An empty Aperture keeps the default.
+//
+// Parameters:
+//   - img: The source image to be blurred
+//   - opts: The aperture and highlight options, Radius 0-200, Threshold 0-1 and Boost 0-100
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError describing the first invalid option
+func BokehStrict(img image.Image, opts BokehOptions) (image.Image, error) {
+	return BokehStrictContext(context.Background(), img, opts, nil)
+}
+
+// BokehStrictContext works like BokehStrict but aborts as soon as ctx is
+// cancelled and reports progress like BokehContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be blurred
+//   - opts: The aperture and highlight options, Radius 0-200, Threshold 0-1 and Boost 0-100
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The blurred image, nil when cancelled or invalid
+//   - error: A *utils.ValidationError describing the first invalid option, or ctx.Err()
+//     when the context was cancelled
+func BokehStrictContext(ctx context.Context, img image.Image, opts BokehOptions, progress utils.ProgressFunc) (image.Image, error) {
+	if err := utils.ValidateRange("radius", opts.Radius, 0, 200); err != nil {
+		return nil, err
+	}
+	if opts.Aperture != "" {
+		if err := utils.ValidateOption("aperture", opts.Aperture, Apertures()); err != nil {
+			return nil, err
+		}
+	}
+	if err := utils.ValidateRange("threshold", opts.Threshold, 0, 1); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("boost", opts.Boost, 0, 100); err != nil {
+		return nil, err
+	}
+	return BokehContext(ctx, img, opts, progress)
+}
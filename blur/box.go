@@ -0,0 +1,215 @@
+package blur
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// slidingLine blurs the n premultiplied pixels of line into out with a box
+// window of the given radius or, for stack, a triangular one. The window is
+// updated with running sums, so the cost per pixel does not depend on the
+// radius. Samples past the ends are mirrored like the Gaussian passes.
+func slidingLine(line, out []float64, n, radius int, stack bool) {
+	at := func(i int) []float64 {
+		i = mirror(i, 0, n)
+		return line[4*i : 4*i+4 : 4*i+4]
+	}
+
+	if !stack {
+		var sum [4]float64
+		for i := -radius; i <= radius; i++ {
+			for c, v := range at(i) {
+				sum[c] += v
+			}
+		}
+		norm := float64(2*radius + 1)
+		for x := 0; x < n; x++ {
+			in, leaving := at(x+radius+1), at(x-radius)
+			for c := range sum {
+				out[4*x+c] = sum[c] / norm
+				sum[c] += in[c] - leaving[c]
+			}
+		}
+		return
+	}
+
+	// The stack weighs the pixel at distance i with radius+1-|i|. Moving it
+	// one pixel adds the pixels right of the centre and removes the centre
+	// and the ones left of it, both kept as running sums.
+	var sum, sumIn, sumOut [4]float64
+	for i := -radius; i <= radius; i++ {
+		weight := float64(radius + 1 - max(i, -i))
+		for c, v := range at(i) {
+			sum[c] += weight * v
+			if i <= 0 {
+				sumOut[c] += v
+			}
+		}
+	}
+	for i := 1; i <= radius+1; i++ {
+		for c, v := range at(i) {
+			sumIn[c] += v
+		}
+	}
+	norm := float64((radius + 1) * (radius + 1))
+	for x := 0; x < n; x++ {
+		next, leaving, entering := at(x+1), at(x-radius), at(x+radius+2)
+		for c := range sum {
+			out[4*x+c] = sum[c] / norm
+			sum[c] += sumIn[c] - sumOut[c]
+			sumOut[c] += next[c] - leaving[c]
+			sumIn[c] += entering[c] - next[c]
+		}
+	}
+}
+
+// toRGBA64 rounds a blurred premultiplied pixel, the sums being exact.
+func toRGBA64(p []float64) color.RGBA64 {
+	channel := func(v float64) uint16 { return uint16(min(v+0.5, 0xffff)) }
+	return color.RGBA64{channel(p[0]), channel(p[1]), channel(p[2]), channel(p[3])}
+}
+
+// slidingBlur runs slidingLine over the rows, then over the columns of the
+// result. The total reported to progress is the number of rows and columns.
+func slidingBlur(ctx context.Context, img image.Image, radius int, stack bool, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if bounds.Empty() {
+		return image.NewRGBA64(bounds), nil
+	}
+
+	var horizontalProgress, verticalProgress utils.ProgressFunc
+	if progress != nil {
+		horizontalProgress = func(done, _ int) { progress(done, height+width) }
+		verticalProgress = func(done, _ int) { progress(height+done, height+width) }
+	}
+
+	reader := pixel.NewReader(img)
+	horizontalFunc := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		line := make([]float64, 4*width)
+		out := make([]float64, 4*width)
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for x := range width {
+				r, g, b, a := reader.RGBA(bounds.Min.X+x, y)
+				line[4*x], line[4*x+1], line[4*x+2], line[4*x+3] = float64(r), float64(g), float64(b), float64(a)
+			}
+			slidingLine(line, out, width, radius, stack)
+			for x := range width {
+				newImage.SetRGBA64(bounds.Min.X+x, y, toRGBA64(out[4*x:4*x+4]))
+			}
+		}
+	}
+	horizontalBlur, err := utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{Image: img, Function: horizontalFunc, Progress: horizontalProgress})
+	if err != nil {
+		return nil, err
+	}
+
+	// The columns are split between the workers, each sliding down the whole
+	// height.
+	reader = pixel.NewReader(horizontalBlur)
+	newImage := image.NewRGBA64(bounds)
+	verticalFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		line := make([]float64, 4*height)
+		out := make([]float64, 4*height)
+		for x := bounds.Min.X + start; x < bounds.Min.X+end; x++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for y := range height {
+				r, g, b, a := reader.RGBA(x, bounds.Min.Y+y)
+				line[4*y], line[4*y+1], line[4*y+2], line[4*y+3] = float64(r), float64(g), float64(b), float64(a)
+			}
+			slidingLine(line, out, height, radius, stack)
+			for y := range height {
+				newImage.SetRGBA64(x, bounds.Min.Y+y, toRGBA64(out[4*y:4*y+4]))
+			}
+		}
+	}
+	_, err = utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{
+		Image:    horizontalBlur,
+		Function: verticalFunc,
+		EndSize:  width,
+		Progress: verticalProgress,
+		NoOutput: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newImage, nil
+}
+
+// BoxBlur replaces each pixel with the average of the square of side
+// 2*radius+1 around it. The window is moved with running sums, so the cost
+// per pixel is the same for any radius, which makes it the fastest blur for
+// large radii. The flat window leaves visible blocky halos around bright
+// details; StackBlur is smoother for the same cost.
+//
+// Parameters:
+//   - img: The source image to be blurred
+//   - radius: The distance in pixels averaged on each side, 0 returns a copy
+//
+// Returns:
+//   - image.Image: A new image with the blur effect applied
+func BoxBlur(img image.Image, radius int) image.Image {
+	newImage, _ := BoxBlurContext(context.Background(), img, radius, nil)
+	return newImage
+}
+
+// BoxBlurContext works like BoxBlur but aborts as soon as ctx is cancelled
+// and reports progress. The total reported to progress covers both passes,
+// the image height plus its width.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be blurred
+//   - radius: The distance in pixels averaged on each side
+//   - progress: Optional callback receiving the completed and total rows and columns, may be nil
+//
+// Returns:
+//   - image.Image: The blurred image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func BoxBlurContext(ctx context.Context, img image.Image, radius int, progress utils.ProgressFunc) (image.Image, error) {
+	return slidingBlur(ctx, img, max(radius, 0), false, progress)
+}
+
+// StackBlur weighs the pixels around each one with a pyramid falling from
+// the centre to the given radius, a close and cheap approximation of a
+// Gaussian blur with a sigma of about radius/2.5. Like BoxBlur its cost per
+// pixel does not depend on the radius.
+//
+// Parameters:
+//   - img: The source image to be blurred
+//   - radius: The distance in pixels reached by the pyramid, 0 returns a copy
+//
+// Returns:
+//   - image.Image: A new image with the blur effect applied
+func StackBlur(img image.Image, radius int) image.Image {
+	newImage, _ := StackBlurContext(context.Background(), img, radius, nil)
+	return newImage
+}
+
+// StackBlurContext works like StackBlur but aborts as soon as ctx is
+// cancelled and reports progress like BoxBlurContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be blurred
+//   - radius: The distance in pixels reached by the pyramid
+//   - progress: Optional callback receiving the completed and total rows and columns, may be nil
+//
+// Returns:
+//   - image.Image: The blurred image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func StackBlurContext(ctx context.Context, img image.Image, radius int, progress utils.ProgressFunc) (image.Image, error) {
+	return slidingBlur(ctx, img, max(radius, 0), true, progress)
+}
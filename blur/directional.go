@@ -0,0 +1,198 @@
+package blur
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/resize"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// maxPathSamples limits the samples averaged for each pixel by the path
+// blurs, spacing them further apart on the longest paths.
+const maxPathSamples = 256
+
+// pathBlur averages for each pixel count(x, y) samples of img, taken at the
+// positions position(x, y, t) for t evenly spaced from 0 to 1. The positions
+// are relative to the top left corner of img, (x, y) being the centre of the
+// pixel, and are sampled bilinearly. The positions outside the image repeat
+// its edges, so the borders do not fade.
+func pathBlur(ctx context.Context, img image.Image, count func(x, y float64) int, position func(x, y, t float64) (float64, float64), progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+	sampler := resize.NewSampler(img, resize.Bilinear)
+
+	pathFunc := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				px, py := float64(x-bounds.Min.X)+0.5, float64(y-bounds.Min.Y)+0.5
+				n := utils.ClampGeneric(count(px, py), 1, maxPathSamples)
+
+				var r, g, b, a float64
+				for i := range n {
+					t := 0.5
+					if n > 1 {
+						t = float64(i) / float64(n-1)
+					}
+					sx, sy := position(px, py, t)
+					c := sampler.At(utils.ClampFloat64(sx, 0.5, width-0.5), utils.ClampFloat64(sy, 0.5, height-0.5))
+					r += float64(c.R)
+					g += float64(c.G)
+					b += float64(c.B)
+					a += float64(c.A)
+				}
+
+				channel := func(v float64) uint16 { return uint16(v/float64(n) + 0.5) }
+				newImage.SetRGBA64(x, y, color.RGBA64{channel(r), channel(g), channel(b), channel(a)})
+			}
+		}
+	}
+	return utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{Image: img, Function: pathFunc, Progress: progress})
+}
+
+// MotionBlur smears the image along a straight line, like a subject moving
+// during the exposure. Each pixel averages the samples of a segment of the
+// given length centred on it.
+//
+// Parameters:
+//   - img: The source image to be blurred
+//   - angle: The direction of the motion in degrees, 0 being horizontal and 90 vertical
+//   - length: The length of the segment in pixels, valid range 0-1000
+//
+// Returns:
+//   - image.Image: A new image with the blur effect applied
+//
+// Note: The samples are taken at subpixel positions, at most 256 per pixel.
+func MotionBlur(img image.Image, angle, length float64) image.Image {
+	newImage, _ := MotionBlurContext(context.Background(), img, angle, length, nil)
+	return newImage
+}
+
+// MotionBlurContext works like MotionBlur but aborts as soon as ctx is
+// cancelled and reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be blurred
+//   - angle: The direction of the motion in degrees
+//   - length: The length of the segment in pixels (0-1000)
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The blurred image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func MotionBlurContext(ctx context.Context, img image.Image, angle, length float64, progress utils.ProgressFunc) (image.Image, error) {
+	length = utils.ClampFloat64(length, 0, 1000)
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	samples := int(math.Ceil(length)) + 1
+
+	return pathBlur(ctx, img,
+		func(x, y float64) int { return samples },
+		func(x, y, t float64) (float64, float64) {
+			d := (t - 0.5) * length
+			return x + d*cos, y + d*sin
+		},
+		progress)
+}
+
+// RadialBlur spins the image around a centre, each pixel averaging the
+// samples of the arc of the given angle through it. The blur grows with the
+// distance to the centre, which stays sharp.
+//
+// Parameters:
+//   - img: The source image to be blurred
+//   - centerX: The horizontal position of the centre, 0 (left edge) to 1 (right edge)
+//   - centerY: The vertical position of the centre, 0 (top edge) to 1 (bottom edge)
+//   - angle: The angle of the arc in degrees, valid range 0-360
+//
+// Returns:
+//   - image.Image: A new image with the blur effect applied
+//
+// Note: The samples are taken at subpixel positions, at most 256 per pixel.
+func RadialBlur(img image.Image, centerX, centerY, angle float64) image.Image {
+	newImage, _ := RadialBlurContext(context.Background(), img, centerX, centerY, angle, nil)
+	return newImage
+}
+
+// RadialBlurContext works like RadialBlur but aborts as soon as ctx is
+// cancelled and reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be blurred
+//   - centerX: The horizontal position of the centre (0-1)
+//   - centerY: The vertical position of the centre (0-1)
+//   - angle: The angle of the arc in degrees (0-360)
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The blurred image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func RadialBlurContext(ctx context.Context, img image.Image, centerX, centerY, angle float64, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	cx, cy := centerX*float64(bounds.Dx()), centerY*float64(bounds.Dy())
+	arc := utils.ClampFloat64(angle, 0, 360) * math.Pi / 180
+
+	return pathBlur(ctx, img,
+		func(x, y float64) int { return int(math.Ceil(math.Hypot(x-cx, y-cy)*arc)) + 1 },
+		func(x, y, t float64) (float64, float64) {
+			sin, cos := math.Sincos((t - 0.5) * arc)
+			dx, dy := x-cx, y-cy
+			return cx + dx*cos - dy*sin, cy + dx*sin + dy*cos
+		},
+		progress)
+}
+
+// ZoomBlur streaks the image towards a centre, like zooming during the
+// exposure. Each pixel averages the samples of the segment joining it to the
+// point strength of the way to the centre, which stays sharp.
+//
+// Parameters:
+//   - img: The source image to be blurred
+//   - centerX: The horizontal position of the centre, 0 (left edge) to 1 (right edge)
+//   - centerY: The vertical position of the centre, 0 (top edge) to 1 (bottom edge)
+//   - strength: The part of the distance to the centre covered, valid range 0-1
+//
+// Returns:
+//   - image.Image: A new image with the blur effect applied
+//
+// Note: The samples are taken at subpixel positions, at most 256 per pixel.
+func ZoomBlur(img image.Image, centerX, centerY, strength float64) image.Image {
+	newImage, _ := ZoomBlurContext(context.Background(), img, centerX, centerY, strength, nil)
+	return newImage
+}
+
+// ZoomBlurContext works like ZoomBlur but aborts as soon as ctx is cancelled
+// and reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be blurred
+//   - centerX: The horizontal position of the centre (0-1)
+//   - centerY: The vertical position of the centre (0-1)
+//   - strength: The part of the distance to the centre covered (0-1)
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The blurred image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func ZoomBlurContext(ctx context.Context, img image.Image, centerX, centerY, strength float64, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	cx, cy := centerX*float64(bounds.Dx()), centerY*float64(bounds.Dy())
+	strength = utils.ClampFloat64(strength, 0, 1)
+
+	return pathBlur(ctx, img,
+		func(x, y float64) int { return int(math.Ceil(math.Hypot(x-cx, y-cy)*strength)) + 1 },
+		func(x, y, t float64) (float64, float64) {
+			scale := 1 - strength*t
+			return cx + (x-cx)*scale, cy + (y-cy)*scale
+		},
+		progress)
+}
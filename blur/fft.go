@@ -0,0 +1,60 @@
+package blur
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// fft transforms data in place with the iterative radix-2 Cooley-Tukey
+// algorithm, len(data) being a power of two. The inverse transform is scaled
+// by 1/len(data), so fft(fft(x), true) returns x.
+func fft(data []complex128, inverse bool) {
+	n := len(data)
+	shift := 64 - bits.TrailingZeros(uint(n))
+	for i := range data {
+		if j := int(bits.Reverse64(uint64(i)) >> shift); j > i {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				even, odd := data[start+k], data[start+k+size/2]*w
+				data[start+k], data[start+k+size/2] = even+odd, even-odd
+				w *= step
+			}
+		}
+	}
+
+	if inverse {
+		scale := complex(1/float64(n), 0)
+		for i := range data {
+			data[i] *= scale
+		}
+	}
+}
+
+// fft2 transforms the n x n row-major data in place, rows then columns.
+func fft2(data []complex128, n int, inverse bool) {
+	for y := range n {
+		fft(data[y*n:(y+1)*n], inverse)
+	}
+	column := make([]complex128, n)
+	for x := range n {
+		for y := range n {
+			column[y] = data[y*n+x]
+		}
+		fft(column, inverse)
+		for y := range n {
+			data[y*n+x] = column[y]
+		}
+	}
+}
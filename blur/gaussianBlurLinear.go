@@ -0,0 +1,123 @@
+package blur
+
+import (
+	"context"
+	"image"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/linear"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// linearBlurPass convolves src with kernel along one axis, reflecting the
+// samples at the image edges like the 16-bit passes.
+func linearBlurPass(ctx context.Context, src *linear.Image, kernel []float64, horizontal bool, progress utils.ProgressFunc) (*linear.Image, error) {
+	bounds := src.Bounds()
+	padding := len(kernel) / 2
+	newImage := linear.NewImage(bounds)
+
+	weights := make([]float32, len(kernel))
+	for i, w := range kernel {
+		weights[i] = float32(w)
+	}
+
+	passFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			o := newImage.PixOffset(bounds.Min.X, y)
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				var r, g, b, a float32
+
+				for k, weight := range weights {
+					sx, sy := x, y
+					if horizontal {
+						sx = mirror(x+k-padding, bounds.Min.X, bounds.Max.X)
+					} else {
+						sy = mirror(y+k-padding, bounds.Min.Y, bounds.Max.Y)
+					}
+
+					i := src.PixOffset(sx, sy)
+					s := src.Pix[i : i+4 : i+4]
+					r += s[0] * weight
+					g += s[1] * weight
+					b += s[2] * weight
+					a += s[3] * weight
+				}
+
+				d := newImage.Pix[o : o+4 : o+4]
+				d[0], d[1], d[2], d[3] = r, g, b, a
+				o += 4
+			}
+		}
+	}
+
+	_, err := utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{Image: src, Function: passFunc, Progress: progress, NoOutput: true})
+	if err != nil {
+		return nil, err
+	}
+	return newImage, nil
+}
+
+// mirror reflects v into [min, max) at the image edges, clamping the
+// positions still outside when the kernel is wider than the image.
+func mirror(v, min, max int) int {
+	if v < min {
+		v = 2*min - v
+	}
+	if v >= max {
+		v = 2*max - v - 1
+	}
+	return utils.ClampGeneric(v, min, max-1)
+}
+
+// GaussianBlurLinear works like GaussianBlur but averages the colours in linear
+// light, avoiding the dark fringes a blur of sRGB values leaves around bright
+// details. The result is a *linear.Image, which can be handed to other linear
+// effects without losing precision.
+//
+// Parameters:
+//   - img: The source image to be blurred, converted with linear.FromImage
+//   - level: The blur intensity, ranging from 0 (no blur) to 30 (maximum blur)
+//
+// Returns:
+//   - image.Image: A *linear.Image with the blur effect applied
+func GaussianBlurLinear(img image.Image, level int) image.Image {
+	newImage, _ := GaussianBlurLinearContext(context.Background(), img, level, nil)
+	return newImage
+}
+
+// GaussianBlurLinearContext works like GaussianBlurLinear but aborts as soon as
+// ctx is cancelled and reports progress like GaussianBlurContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image to be blurred
+//   - level: The blur intensity (0-30)
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: A *linear.Image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func GaussianBlurLinearContext(ctx context.Context, img image.Image, level int, progress utils.ProgressFunc) (image.Image, error) {
+	level = utils.ClampGeneric(level, 0, 30)
+	kernel := createKernel(level)
+
+	var horizontalProgress, verticalProgress utils.ProgressFunc
+	if progress != nil {
+		horizontalProgress = func(done, total int) { progress(done, 2*total) }
+		verticalProgress = func(done, total int) { progress(total+done, 2*total) }
+	}
+
+	horizontalBlur, err := linearBlurPass(ctx, linear.FromImage(img), kernel, true, horizontalProgress)
+	if err != nil {
+		return nil, err
+	}
+	newImage, err := linearBlurPass(ctx, horizontalBlur, kernel, false, verticalProgress)
+	if err != nil {
+		return nil, err
+	}
+	return newImage, nil
+}
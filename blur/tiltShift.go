@@ -0,0 +1,57 @@
+package blur
+
+import (
+	"context"
+	"image"
+
+	"github.com/BrunoPoiano/imgeffects/mask"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// TiltShift imitates the shallow depth of field of a tilt-shift lens, which
+// makes landscapes look like miniatures: a horizontal band stays sharp and
+// the image is blurred more and more above and below it. It blends a
+// GaussianBlur of the image through a mask.Band.
+//
+// Parameters:
+//   - img: The source image
+//   - center: The vertical position of the sharp band, 0 (top) to 1 (bottom)
+//   - size: The height of the sharp band, in fractions of the image height
+//   - feather: The height of the transition to the full blur on each side, in fractions of
+//     the image height
+//   - level: The blur intensity of GaussianBlur (0-30)
+//
+// Returns:
+//   - image.Image
+//
+// Example:
+//
+//	miniature := blur.TiltShift(img, 0.6, 0.15, 0.25, 12)
+func TiltShift(img image.Image, center, size, feather float64, level int) image.Image {
+	newImage, _ := TiltShiftContext(context.Background(), img, center, size, feather, level, nil)
+	return newImage
+}
+
+// TiltShiftContext works like TiltShift but aborts as soon as ctx is
+// cancelled and reports progress like mask.ApplyEffectContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image
+//   - center: The vertical position of the sharp band (0-1)
+//   - size: The height of the sharp band
+//   - feather: The height of the transition on each side
+//   - level: The blur intensity (0-30)
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The blurred image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func TiltShiftContext(ctx context.Context, img image.Image, center, size, feather float64, level int, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	band := mask.Band(bounds.Dx(), bounds.Dy(), center, size, feather, 0)
+	blur := func(ctx context.Context, img image.Image, progress utils.ProgressFunc) (image.Image, error) {
+		return GaussianBlurContext(ctx, img, level, progress)
+	}
+	return mask.ApplyEffectContext(ctx, img, band, blur, progress)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BrunoPoiano/imgeffects/batch"
+	"github.com/BrunoPoiano/imgeffects/effects"
+)
+
+// runBatch implements
+//
+//	imgeffects [options] batch [batch flags] <input dir|glob> <output dir> [<effect> [flags] [+ ...]]
+//
+// The effect chain is omitted when the -recipe option is used.
+func runBatch(args []string, recipe string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	workers := fs.Int("workers", 0, "number of images processed at the same time (default number of CPUs)")
+	recursive := fs.Bool("recursive", false, "process sub directories of the input directory")
+	format := fs.String("format", "", "output format extension (png, jpg, gif), defaults to the input extension")
+	force := fs.Bool("force", false, "process images whose output is already up to date")
+	report := fs.String("report", "", "write a JSON report of the run to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: imgeffects [options] batch [batch flags] <input dir|glob> <output dir> [<effect> [flags] [+ ...]]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	args = fs.Args()
+	if len(args) < 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	input, output := args[0], args[1]
+	args = args[2:]
+
+	var pipeline *effects.Pipeline
+	var err error
+
+	if recipe != "" {
+		pipeline, err = effects.LoadRecipe(recipe)
+	} else {
+		pipeline, args, err = parseChain(args, stderr)
+	}
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected arguments %q", args)
+	}
+
+	result, err := batch.Run(batch.Options{
+		Pipeline:  pipeline,
+		Input:     input,
+		OutputDir: output,
+		Recursive: *recursive,
+		Format:    *format,
+		Workers:   *workers,
+		Force:     *force,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *report != "" {
+		file, err := os.Create(*report)
+		if err != nil {
+			return err
+		}
+		if err := result.WriteJSON(file); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+
+	if err := result.WriteSummary(stdout); err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return errors.New("some images failed to process")
+	}
+	return nil
+}
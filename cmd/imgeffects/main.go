@@ -0,0 +1,271 @@
+// Command imgeffects applies image effects from the command line.
+//
+// Usage:
+//
+//	imgeffects list
+//	imgeffects help <effect>
+//	imgeffects [options] <effect> [effect flags] [+ <effect> [effect flags]]... <input> <output>
+//	imgeffects [options] -recipe <recipe.json> <input> <output>
+//	imgeffects [options] batch [batch flags] <input dir|glob> <output dir> [<effect> [flags] [+ ...]]
+//
+// Effects are chained with "+", for example:
+//
+//	imgeffects unsharp-masking --variation 0.6 + dither --algorithm atkinson --level 4 in.png out.png
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/BrunoPoiano/imgeffects"
+	"github.com/BrunoPoiano/imgeffects/effects"
+	"github.com/BrunoPoiano/imgeffects/imageio"
+	"github.com/BrunoPoiano/imgeffects/mask"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+const chainSeparator = "+"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "imgeffects:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("imgeffects", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	recipe := fs.String("recipe", "", "apply the effects of a JSON recipe")
+	saveRecipe := fs.String("save-recipe", "", "save the effect chain as a JSON recipe")
+	maskPath := fs.String("mask", "", "apply the effects only where this grayscale mask image is white")
+	quality := fs.Int("quality", imageio.JPEGQuality, "JPEG output quality (1-100)")
+	autoOrient := fs.Bool("auto-orient", imageio.AutoOrient, "turn JPEG inputs upright according to their EXIF orientation")
+	threads := fs.Int("threads", 0, "maximum goroutines used by each effect (default GOMAXPROCS)")
+	verbose := fs.Bool("v", false, "log how the work is distributed")
+	version := fs.Bool("version", false, "print the version and exit")
+	fs.Usage = func() { usage(stderr, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	imageio.JPEGQuality = *quality
+	imageio.AutoOrient = *autoOrient
+
+	opts := utils.Options{MaxWorkers: *threads}
+	if *verbose {
+		opts.Logger = func(format string, args ...any) {
+			fmt.Fprintf(stderr, format+"\n", args...)
+		}
+	}
+	utils.SetDefaultOptions(opts)
+
+	if *version {
+		fmt.Fprintln(stdout, imgeffects.Version)
+		return nil
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	switch args[0] {
+	case "list":
+		return list(stdout)
+	case "help":
+		if len(args) != 2 {
+			return errors.New("usage: imgeffects help <effect>")
+		}
+		return help(stdout, args[1])
+	case "batch":
+		if *maskPath != "" {
+			return errors.New("the -mask option is not supported by batch")
+		}
+		return runBatch(args[1:], *recipe, stdout, stderr)
+	}
+
+	var pipeline *effects.Pipeline
+	var err error
+
+	if *recipe != "" {
+		pipeline, err = effects.LoadRecipe(*recipe)
+	} else {
+		pipeline, args, err = parseChain(args, stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("expected <input> <output>, got %d arguments", len(args))
+	}
+
+	if *saveRecipe != "" {
+		if err := pipeline.SaveRecipe(*saveRecipe); err != nil {
+			return err
+		}
+	}
+
+	return process(pipeline, args[0], args[1], *maskPath)
+}
+
+// process applies the pipeline to the input image, through the mask image at
+// maskPath unless it is empty, and saves the result.
+func process(pipeline *effects.Pipeline, input, output, maskPath string) error {
+	img, _, err := imageio.Load(input)
+	if err != nil {
+		return err
+	}
+
+	if maskPath == "" {
+		newImage, err := pipeline.Run(img)
+		if err != nil {
+			return err
+		}
+		return imageio.Save(output, newImage)
+	}
+
+	// The mask only lines up with the input when the chain keeps its size.
+	m, _, err := imageio.Load(maskPath)
+	if err != nil {
+		return err
+	}
+	newImage, err := mask.ApplyEffectContext(context.Background(), img, m, func(_ context.Context, img image.Image, _ utils.ProgressFunc) (image.Image, error) {
+		return pipeline.Run(img)
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return imageio.Save(output, newImage)
+}
+
+// parseChain reads "<effect> [flags] [+ <effect> [flags]]..." from args
+// and returns the pipeline together with the remaining positional arguments.
+func parseChain(args []string, stderr io.Writer) (*effects.Pipeline, []string, error) {
+	pipeline := effects.NewPipeline()
+
+	for {
+		if len(args) == 0 {
+			return nil, nil, errors.New("missing effect name")
+		}
+
+		def, ok := effects.Lookup(args[0])
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown effect %q (see imgeffects list)", args[0])
+		}
+
+		fs, values := effectFlags(def, stderr)
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, nil, err
+		}
+		pipeline.Add(def.Name, values())
+
+		args = fs.Args()
+		if len(args) == 0 || args[0] != chainSeparator {
+			break
+		}
+		args = args[1:]
+	}
+
+	if _, err := pipeline.Effects(); err != nil {
+		return nil, nil, err
+	}
+
+	return pipeline, args, nil
+}
+
+// effectFlags builds a flag set from the parameters of def. The returned
+// function collects the values of the flags that were explicitly set.
+func effectFlags(def effects.Definition, stderr io.Writer) (*flag.FlagSet, func() effects.Values) {
+	fs := flag.NewFlagSet(def.Name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	getters := map[string]func() any{}
+
+	for _, p := range def.Params {
+		usage := paramUsage(p)
+		switch p.Type {
+		case effects.Int:
+			v := fs.Int(p.Name, p.Default.(int), usage)
+			getters[p.Name] = func() any { return *v }
+		case effects.Float:
+			v := fs.Float64(p.Name, p.Default.(float64), usage)
+			getters[p.Name] = func() any { return *v }
+		case effects.Bool:
+			v := fs.Bool(p.Name, p.Default.(bool), usage)
+			getters[p.Name] = func() any { return *v }
+		case effects.String:
+			v := fs.String(p.Name, p.Default.(string), usage)
+			getters[p.Name] = func() any { return *v }
+		}
+	}
+
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "%s: %s\n", def.Name, def.Description)
+		fs.PrintDefaults()
+	}
+
+	return fs, func() effects.Values {
+		values := effects.Values{}
+		fs.Visit(func(f *flag.Flag) {
+			values[f.Name] = getters[f.Name]()
+		})
+		return values
+	}
+}
+
+func paramUsage(p effects.Param) string {
+	usage := p.Description
+	switch p.Type {
+	case effects.Int, effects.Float:
+		usage += fmt.Sprintf(" (%g to %g)", p.Min, p.Max)
+	case effects.String:
+		if len(p.Options) > 0 {
+			usage += " (" + strings.Join(p.Options, ", ") + ")"
+		}
+	}
+	return usage
+}
+
+func list(w io.Writer) error {
+	for _, def := range effects.List() {
+		fmt.Fprintf(w, "%s\n    %s\n", def.Name, def.Description)
+		for _, p := range def.Params {
+			fmt.Fprintf(w, "    --%s %s: %s [default %v]\n", p.Name, p.Type, paramUsage(p), p.Default)
+		}
+	}
+	return nil
+}
+
+func help(w io.Writer, name string) error {
+	def, ok := effects.Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown effect %q (see imgeffects list)", name)
+	}
+
+	fs, _ := effectFlags(def, w)
+	fs.Usage()
+	return nil
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprint(w, `Usage:
+  imgeffects list
+  imgeffects help <effect>
+  imgeffects [options] <effect> [effect flags] [+ <effect> [effect flags]]... <input> <output>
+  imgeffects [options] -recipe <recipe.json> <input> <output>
+  imgeffects [options] batch [batch flags] <input dir|glob> <output dir> [<effect> [flags] [+ ...]]
+
+Options:
+`)
+	fs.PrintDefaults()
+}
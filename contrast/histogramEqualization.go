@@ -0,0 +1,306 @@
+package contrast
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// levels is the number of histogram bins of the equalizations.
+const levels = 256
+
+// luminances returns the luminance in [0, 1] of every pixel of img, row by
+// row, -1 marking the transparent pixels left out of the histograms.
+func luminances(img image.Image) []float64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	reader := pixel.NewReader(img)
+	values := make([]float64, width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b, a := reader.RGBA(bounds.Min.X+x, bounds.Min.Y+y)
+			if a == 0 {
+				values[y*width+x] = -1
+				continue
+			}
+			r, g, b = utils.Unpremultiply(r, g, b, a)
+			values[y*width+x] = min(utils.Luminance16bit(r, g, b)/0xffff, 1)
+		}
+	}
+	return values
+}
+
+// level returns the histogram bin of a luminance.
+func level(luminance float64) int {
+	return utils.ClampGeneric(int(luminance*(levels-1)+0.5), 0, levels-1)
+}
+
+// lookup maps a luminance through a table of levels entries, interpolating
+// between them so 16-bit images keep their smooth gradients.
+func lookup(table []float64, luminance float64) float64 {
+	position := luminance * (levels - 1)
+	i := utils.ClampGeneric(int(position), 0, levels-2)
+	f := utils.ClampFloat64(position-float64(i), 0, 1)
+	return table[i]*(1-f) + table[i+1]*f
+}
+
+// relight changes the luminance of a straight colour in [0, 1] to target
+// while keeping its hue: the channels are scaled together and, when one of
+// them would exceed 1, the colour is desaturated towards the gray of the
+// target luminance instead of clipped.
+func relight(r, g, b, luminance, target float64) (float64, float64, float64) {
+	if luminance <= 0 {
+		return target, target, target
+	}
+	scale := target / luminance
+	r, g, b = r*scale, g*scale, b*scale
+	if m := max(r, g, b); m > 1 {
+		t := (1 - target) / (m - target)
+		r, g, b = target+(r-target)*t, target+(g-target)*t, target+(b-target)*t
+	}
+	return r, g, b
+}
+
+// equalize rebuilds img with the luminance of each pixel, given its
+// position relative to the top left corner, mapped by mapping.
+func equalize(ctx context.Context, img image.Image, values []float64, mapping func(x, y int, luminance float64) float64, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	reader := pixel.NewReader(img)
+
+	equalizeFunc := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			py := y - bounds.Min.Y
+			for px := range width {
+				luminance := values[py*width+px]
+				if luminance < 0 {
+					continue
+				}
+				r, g, b, a := reader.RGBA(bounds.Min.X+px, y)
+				r, g, b = utils.Unpremultiply(r, g, b, a)
+				target := utils.ClampFloat64(mapping(px, py, luminance), 0, 1)
+				nr, ng, nb := relight(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff, luminance, target)
+
+				// Back to premultiplied values.
+				alpha := float64(a) / 0xffff
+				channel := func(v float64) uint16 { return uint16(utils.ClampFloat64(v, 0, 1)*alpha*0xffff + 0.5) }
+				newImage.SetRGBA64(bounds.Min.X+px, y, color.RGBA64{channel(nr), channel(ng), channel(nb), uint16(a)})
+			}
+		}
+	}
+	return utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{Image: img, Function: equalizeFunc, Progress: progress})
+}
+
+// HistogramEqualization spreads the luminance of an image over the whole
+// range so every level is used about as often, revealing the details of
+// faded or underexposed images. Only the luminance is equalized, the colours
+// keep their hue.
+//
+// Parameters:
+//   - img: The input image to be enhanced
+//
+// Returns:
+//   - image.Image
+//
+// Note: Transparent pixels are left out of the histogram and stay transparent.
+func HistogramEqualization(img image.Image) image.Image {
+	newImage, _ := HistogramEqualizationContext(context.Background(), img, nil)
+	return newImage
+}
+
+// HistogramEqualizationContext works like HistogramEqualization but aborts
+// as soon as ctx is cancelled and reports the number of completed rows to
+// progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be enhanced
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The equalized image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func HistogramEqualizationContext(ctx context.Context, img image.Image, progress utils.ProgressFunc) (image.Image, error) {
+	values := luminances(img)
+	histogram := make([]float64, levels)
+	for _, luminance := range values {
+		if luminance >= 0 {
+			histogram[level(luminance)]++
+		}
+	}
+
+	// The cumulative distribution, starting from the first used level so
+	// the darkest pixels become black.
+	table := make([]float64, levels)
+	var first, total float64
+	for i, count := range histogram {
+		if first == 0 {
+			first = count
+		}
+		total += count
+		table[i] = total
+	}
+	for i := range table {
+		if total > first {
+			table[i] = max(table[i]-first, 0) / (total - first)
+		} else {
+			// A single level has nothing to spread.
+			table[i] = float64(i) / (levels - 1)
+		}
+	}
+
+	return equalize(ctx, img, values, func(_, _ int, luminance float64) float64 {
+		return lookup(table, luminance)
+	}, progress)
+}
+
+// CLAHE applies Contrast Limited Adaptive Histogram Equalization: the image
+// is divided into a grid of tiles, each equalized on its own so the local
+// details come out in both the dark and the bright areas, and the mappings
+// are interpolated between the tile centres so the tiles do not show. The
+// clip limit caps how often a level may count in a tile histogram, which
+// keeps the noise of flat areas from being amplified. Like
+// HistogramEqualization only the luminance changes.
+//
+// Parameters:
+//   - img: The input image to be enhanced
+//   - tilesX, tilesY: The number of tiles across and down (1-64)
+//   - clipLimit: The maximum count of a level relative to the average count (1-100).
+//     1 leaves the image almost unchanged, higher values increase the contrast.
+//
+// Returns:
+//   - image.Image
+//
+// Note: The values outside the ranges are clamped.
+//
+// Example:
+//
+//	enhanced := contrast.CLAHE(scan, 8, 8, 2)
+func CLAHE(img image.Image, tilesX, tilesY int, clipLimit float64) image.Image {
+	newImage, _ := CLAHEContext(context.Background(), img, tilesX, tilesY, clipLimit, nil)
+	return newImage
+}
+
+// CLAHEContext works like CLAHE but aborts as soon as ctx is cancelled and
+// reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be enhanced
+//   - tilesX, tilesY: The number of tiles across and down (1-64)
+//   - clipLimit: The maximum count of a level relative to the average count (1-100)
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The equalized image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func CLAHEContext(ctx context.Context, img image.Image, tilesX, tilesY int, clipLimit float64, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// A tile holds at least one pixel.
+	tilesX = utils.ClampGeneric(tilesX, 1, max(min(64, width), 1))
+	tilesY = utils.ClampGeneric(tilesY, 1, max(min(64, height), 1))
+	clipLimit = utils.ClampFloat64(clipLimit, 1, 100)
+	values := luminances(img)
+
+	tables := make([][]float64, tilesX*tilesY)
+	histogram := make([]float64, levels)
+	for ty := range tilesY {
+		for tx := range tilesX {
+			clear(histogram)
+			var count float64
+			for y := ty * height / tilesY; y < (ty+1)*height/tilesY; y++ {
+				for x := tx * width / tilesX; x < (tx+1)*width/tilesX; x++ {
+					if luminance := values[y*width+x]; luminance >= 0 {
+						histogram[level(luminance)]++
+						count++
+					}
+				}
+			}
+
+			table := make([]float64, levels)
+			tables[ty*tilesX+tx] = table
+			if count == 0 {
+				for i := range table {
+					table[i] = float64(i) / (levels - 1)
+				}
+				continue
+			}
+
+			// Clip the histogram and share the excess between all the
+			// levels, again until almost nothing is left over.
+			limit := clipLimit * count / levels
+			for range 16 {
+				var excess float64
+				for i, c := range histogram {
+					if c > limit {
+						excess += c - limit
+						histogram[i] = limit
+					}
+				}
+				if excess < 1e-6*count {
+					break
+				}
+				for i := range histogram {
+					histogram[i] += excess / levels
+				}
+			}
+
+			var total float64
+			for i, c := range histogram {
+				total += c
+				table[i] = total / count
+			}
+		}
+	}
+
+	// The position of a pixel in tile units, the tile centres being at
+	// whole values, and the two tiles interpolated with their weight.
+	neighbours := func(p, size, tiles int) (int, int, float64) {
+		f := (float64(p)+0.5)*float64(tiles)/float64(size) - 0.5
+		i0 := utils.ClampGeneric(int(math.Floor(f)), 0, tiles-1)
+		i1 := min(i0+1, tiles-1)
+		return i0, i1, utils.ClampFloat64(f-float64(i0), 0, 1)
+	}
+
+	return equalize(ctx, img, values, func(x, y int, luminance float64) float64 {
+		x0, x1, wx := neighbours(x, width, tilesX)
+		y0, y1, wy := neighbours(y, height, tilesY)
+		top := lookup(tables[y0*tilesX+x0], luminance)*(1-wx) + lookup(tables[y0*tilesX+x1], luminance)*wx
+		bottom := lookup(tables[y1*tilesX+x0], luminance)*(1-wx) + lookup(tables[y1*tilesX+x1], luminance)*wx
+		return top*(1-wy) + bottom*wy
+	}, progress)
+}
+
+// CLAHEStrict works like CLAHE but returns an error for options out of
+// their ranges.
+//
+// Parameters:
+//   - img: The input image to be enhanced
+//   - tilesX, tilesY: The number of tiles across and down (1-64)
+//   - clipLimit: The maximum count of a level relative to the average count (1-100)
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError describing the first invalid option
+func CLAHEStrict(img image.Image, tilesX, tilesY int, clipLimit float64) (image.Image, error) {
+	if err := utils.ValidateRange("tiles-x", tilesX, 1, 64); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("tiles-y", tilesY, 1, 64); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("clip-limit", clipLimit, 1, 100); err != nil {
+		return nil, err
+	}
+	return CLAHE(img, tilesX, tilesY, clipLimit), nil
+}
@@ -3,6 +3,9 @@ package contrast
 import (
 	"image"
 	"image/color"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
 // LinearContrastStretchingGrayscale applies Linear Contrast Stretching to an image and converts it to grayscale.
@@ -20,14 +23,20 @@ import (
 func LinearContrastStretchingGrayscale(img image.Image) image.Image {
 
 	bounds := img.Bounds()
-	newImage := image.NewRGBA(bounds)
+	newImage := image.NewNRGBA(bounds)
+	reader := pixel.NewReader(img)
 
 	//intensity
 	I_min, I_max := uint8(255), uint8(0)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			if a == 0 {
+				// Transparent pixels have no colour to stretch.
+				continue
+			}
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			gray := uint8((r + g + b) / 3 >> 8)
 			if uint8(gray) < I_min {
 				I_min = uint8(gray)
@@ -39,13 +48,14 @@ func LinearContrastStretchingGrayscale(img image.Image) image.Image {
 		}
 	}
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			gray := uint8((r + g + b) / 3 >> 8)
 			I_out := ((I_max - I_min) / 255) * (gray - I_min)
 
-			newImage.Set(x, y, color.Gray{I_out})
+			newImage.SetNRGBA(x, y, color.NRGBA{I_out, I_out, I_out, uint8(a >> 8)})
 		}
 	}
 
@@ -68,16 +78,21 @@ func LinearContrastStretchingGrayscale(img image.Image) image.Image {
 func LinearContrastStretching(img image.Image) image.Image {
 
 	bounds := img.Bounds()
-	newImage := image.NewRGBA(bounds)
+	newImage := image.NewNRGBA(bounds)
+	reader := pixel.NewReader(img)
 
 	//intensity
 	R_min, R_max := uint8(255), uint8(0)
 	G_min, G_max := uint8(255), uint8(0)
 	B_min, B_max := uint8(255), uint8(0)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			if a == 0 {
+				continue
+			}
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			rr := uint8(r >> 8)
 			gg := uint8(g >> 8)
 			bb := uint8(b >> 8)
@@ -105,9 +120,10 @@ func LinearContrastStretching(img image.Image) image.Image {
 		}
 	}
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 
 			rr := uint8(r >> 8)
 			gg := uint8(g >> 8)
@@ -122,7 +138,7 @@ func LinearContrastStretching(img image.Image) image.Image {
 				return uint8(255 * (float64(value-min) / float64(max-min)))
 			}
 
-			newImage.Set(x, y, color.RGBA{
+			newImage.SetNRGBA(x, y, color.NRGBA{
 				stretch(rr, R_min, R_max),
 				stretch(gg, G_min, G_max),
 				stretch(bb, B_min, B_max),
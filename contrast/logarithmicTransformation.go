@@ -4,6 +4,9 @@ import (
 	"image"
 	"image/color"
 	"math"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
 // LogarithmicTransformation applies logarithmic transformation to an image,
@@ -23,7 +26,8 @@ import (
 func LogarithmicTransformation(img image.Image, variation float64) image.Image {
 
 	bounds := img.Bounds()
-	newImage := image.NewRGBA64(bounds)
+	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
 	calc := func(value uint32) uint16 {
 		switch variation {
@@ -37,11 +41,12 @@ func LogarithmicTransformation(img image.Image, variation float64) image.Image {
 		return uint16(transformed * 65535.0)
 	}
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 
-			newImage.Set(x, y, color.RGBA64{
+			newImage.SetNRGBA64(x, y, color.NRGBA64{
 				calc(r),
 				calc(g),
 				calc(b),
@@ -5,6 +5,7 @@ import (
 	"image/color"
 
 	"github.com/BrunoPoiano/imgeffects/blur"
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -29,23 +30,26 @@ func UnsharpMasking(img image.Image, variation float64, blurLevel int) image.Ima
 	bounds := img.Bounds()
 	newImage := image.NewRGBA64(bounds)
 	bluredImage := blur.GaussianBlur(img, blurLevel)
+	reader := pixel.NewReader(img)
+	bluredReader := pixel.NewReader(bluredImage)
 
-	stretch := func(value, bluredValue uint32) uint16 {
+	// The channels are premultiplied, so the result is limited to the alpha of the pixel.
+	stretch := func(value, bluredValue, alpha uint32) uint16 {
 
 		diff := int32(value) - int32(bluredValue)
 		result := int32(value) + int32(float64(diff)*variation)
-		return utils.Clamp16bit(result)
+		return min(utils.Clamp16bit(result), uint16(alpha))
 	}
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
-			br, bg, bb, _ := bluredImage.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			br, bg, bb, _ := bluredReader.RGBA(x, y)
 
-			newImage.Set(x, y, color.RGBA64{
-				stretch(r, br),
-				stretch(g, bg),
-				stretch(b, bb),
+			newImage.SetRGBA64(x, y, color.RGBA64{
+				stretch(r, br, a),
+				stretch(g, bg, a),
+				stretch(b, bb, a),
 				uint16(a),
 			})
 		}
@@ -3,11 +3,54 @@ package dithering
 import (
 	"image"
 	"image/color"
+	"image/draw"
 	"math"
 
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
+// Algorithm is an error diffusion algorithm.
+type Algorithm string
+
+const (
+	FloydSteinberg      Algorithm = "floyd-steinberg"
+	FalseFloydSteinberg Algorithm = "false-floyd-steinberg"
+	JarvisJudiceNinke   Algorithm = "jarvis-judice-ninke"
+	Stucki              Algorithm = "stucki"
+	Atkinson            Algorithm = "atkinson"
+	Sierra              Algorithm = "sierra"
+	TwoRowSierra        Algorithm = "two-row-seirra"
+	SierraLite          Algorithm = "sierra-lite"
+	None                Algorithm = "none"
+)
+
+// Algorithms returns every supported error diffusion algorithm.
+//
+// Returns:
+//   - []Algorithm
+func Algorithms() []Algorithm {
+	return []Algorithm{
+		FloydSteinberg, FalseFloydSteinberg, JarvisJudiceNinke, Stucki,
+		Atkinson, Sierra, TwoRowSierra, SierraLite, None,
+	}
+}
+
+// ParseAlgorithm returns the Algorithm named s.
+//
+// Parameters:
+//   - s: The name of the algorithm (case-sensitive)
+//
+// Returns:
+//   - Algorithm
+//   - error: A *utils.ValidationError listing the supported algorithms when s is unknown
+func ParseAlgorithm(s string) (Algorithm, error) {
+	algorithm := Algorithm(s)
+	if err := utils.ValidateOption("algorithm", algorithm, Algorithms()); err != nil {
+		return "", err
+	}
+	return algorithm, nil
+}
+
 // ErrorDiffusionDithering applies an error diffusion dithering effect to an image.
 // This technique distributes the quantization error of a pixel to neighboring
 // pixels according to different distribution patterns defined by various algorithms.
@@ -31,8 +74,29 @@ import (
 //     Higher values (8-10) produce more subtle dithering with greater color depth.
 //
 // Returns:
-//   - image.Image: A new RGBA image with the dithering effect applied
+//   - image.Image: A new NRGBA image with the dithering effect applied
+//
+// An unknown algorithm is treated like "none", use ErrorDifusionDitheringStrict to reject it.
+// The alpha channel is dithered too, use ErrorDifusionDitheringKeepAlpha to leave it untouched.
 func ErrorDifusionDithering(img image.Image, algorithm string, level int) image.Image {
+	return errorDifusionDithering(img, algorithm, level, false)
+}
+
+// ErrorDifusionDitheringKeepAlpha works like ErrorDifusionDithering but only
+// quantizes the colour channels, keeping the alpha of every pixel.
+//
+// Parameters:
+//   - img: The input image to be processed
+//   - algorithm: The name of the dithering algorithm to use (case-sensitive)
+//   - level: The number of quantization levels per channel (1-10)
+//
+// Returns:
+//   - image.Image: A new NRGBA image with the dithering effect applied
+func ErrorDifusionDitheringKeepAlpha(img image.Image, algorithm string, level int) image.Image {
+	return errorDifusionDithering(img, algorithm, level, true)
+}
+
+func errorDifusionDithering(img image.Image, algorithm string, level int, keepAlpha bool) image.Image {
 
 	level = utils.ClampGeneric(level, 1, 10)
 
@@ -43,39 +107,40 @@ func ErrorDifusionDithering(img image.Image, algorithm string, level int) image.
 		return newValue, int(value) - int(newValue)
 	}
 
+	// The error is diffused between straight colours, quantizing
+	// premultiplied values would darken semi-transparent pixels.
 	bounds := img.Bounds()
-	image := image.NewRGBA(bounds)
+	image := image.NewNRGBA(bounds)
 
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			image.Set(x, y, img.At(x, y))
-		}
-	}
+	draw.Draw(image, bounds, img, bounds.Min, draw.Src)
 
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			oldPix := image.RGBAAt(x, y)
+			oldPix := image.NRGBAAt(x, y)
 
 			newR, errR := quantize(oldPix.R, level)
 			newG, errG := quantize(oldPix.G, level)
 			newB, errB := quantize(oldPix.B, level)
-			newA, errA := quantize(oldPix.A, level)
+			newA, errA := oldPix.A, 0
+			if !keepAlpha {
+				newA, errA = quantize(oldPix.A, level)
+			}
 
-			image.SetRGBA(x, y, color.RGBA{uint8(newR), uint8(newG), uint8(newB), uint8(newA)})
+			image.SetNRGBA(x, y, color.NRGBA{newR, newG, newB, newA})
 
-			switch algorithm {
-			case "floyd-steinberg":
+			switch Algorithm(algorithm) {
+			case FloydSteinberg:
 				makeDither(image, x+1, y, errR, errG, errB, errA, 7.0/16)
 				makeDither(image, x-1, y+1, errR, errG, errB, errA, 3.0/16)
 				makeDither(image, x, y+1, errR, errG, errB, errA, 5.0/16)
 				makeDither(image, x+1, y+1, errR, errG, errB, errA, 1.0/16)
 
-			case "false-floyd-steinberg":
+			case FalseFloydSteinberg:
 				makeDither(image, x+1, y, errR, errG, errB, errA, 3.0/8)
 				makeDither(image, x, y+1, errR, errG, errB, errA, 3.0/8)
 				makeDither(image, x+1, y+1, errR, errG, errB, errA, 2.0/8)
 
-			case "jarvis-judice-ninke":
+			case JarvisJudiceNinke:
 				makeDither(image, x+1, y, errR, errG, errB, errA, 7.0/48)
 				makeDither(image, x+2, y, errR, errG, errB, errA, 5.0/48)
 				makeDither(image, x-2, y+1, errR, errG, errB, errA, 3.0/48)
@@ -89,7 +154,7 @@ func ErrorDifusionDithering(img image.Image, algorithm string, level int) image.
 				makeDither(image, x+1, y+2, errR, errG, errB, errA, 3.0/48)
 				makeDither(image, x+2, y+2, errR, errG, errB, errA, 1.0/48)
 
-			case "stucki":
+			case Stucki:
 				makeDither(image, x+1, y, errR, errG, errB, errA, 8.0/42)
 				makeDither(image, x+2, y, errR, errG, errB, errA, 4.0/42)
 				makeDither(image, x-2, y+1, errR, errG, errB, errA, 2.0/42)
@@ -103,7 +168,7 @@ func ErrorDifusionDithering(img image.Image, algorithm string, level int) image.
 				makeDither(image, x+1, y+2, errR, errG, errB, errA, 2.0/42)
 				makeDither(image, x+2, y+2, errR, errG, errB, errA, 1.0/42)
 
-			case "atkinson":
+			case Atkinson:
 				makeDither(image, x+1, y, errR, errG, errB, errA, 1.0/8)
 				makeDither(image, x+2, y, errR, errG, errB, errA, 1.0/8)
 				makeDither(image, x-1, y+1, errR, errG, errB, errA, 1.0/8)
@@ -111,7 +176,7 @@ func ErrorDifusionDithering(img image.Image, algorithm string, level int) image.
 				makeDither(image, x+1, y+1, errR, errG, errB, errA, 1.0/8)
 				makeDither(image, x, y+2, errR, errG, errB, errA, 1.0/8)
 
-			case "sierra":
+			case Sierra:
 				makeDither(image, x+1, y, errR, errG, errB, errA, 5.0/32)
 				makeDither(image, x+2, y, errR, errG, errB, errA, 3.0/32)
 				makeDither(image, x+2, y+1, errR, errG, errB, errA, 2.0/32)
@@ -122,7 +187,7 @@ func ErrorDifusionDithering(img image.Image, algorithm string, level int) image.
 				makeDither(image, x, y+2, errR, errG, errB, errA, 3.0/32)
 				makeDither(image, x+1, y+2, errR, errG, errB, errA, 2.0/32)
 
-			case "two-row-seirra":
+			case TwoRowSierra:
 				makeDither(image, x+1, y, errR, errG, errB, errA, 4.0/16)
 				makeDither(image, x+2, y, errR, errG, errB, errA, 3.0/16)
 				makeDither(image, x-2, y+1, errR, errG, errB, errA, 2.0/16)
@@ -131,12 +196,12 @@ func ErrorDifusionDithering(img image.Image, algorithm string, level int) image.
 				makeDither(image, x+1, y+1, errR, errG, errB, errA, 2.0/16)
 				makeDither(image, x+2, y+1, errR, errG, errB, errA, 1.0/16)
 
-			case "sierra-lite":
+			case SierraLite:
 				makeDither(image, x+1, y, errR, errG, errB, errA, 2.0/4)
 				makeDither(image, x-1, y+1, errR, errG, errB, errA, 1.0/4)
 				makeDither(image, x, y+1, errR, errG, errB, errA, 1.0/4)
 
-			case "none":
+			case None:
 			}
 		}
 	}
@@ -144,17 +209,60 @@ func ErrorDifusionDithering(img image.Image, algorithm string, level int) image.
 	return image
 }
 
-func makeDither(img *image.RGBA, x, y int, r, g, b, a int, factor float64) {
+// ErrorDifusionDitheringStrict works like ErrorDifusionDithering but returns an
+// error instead of ignoring an unknown algorithm or clamping the level.
+//
+// Parameters:
+//   - img: The input image to be processed
+//   - algorithm: The dithering algorithm, one of Algorithms()
+//   - level: The number of quantization levels per channel (1-10)
+//
+// Returns:
+//   - image.Image: A new NRGBA image with the dithering effect applied
+//   - error: A *utils.ValidationError describing the first invalid parameter
+func ErrorDifusionDitheringStrict(img image.Image, algorithm Algorithm, level int) (image.Image, error) {
+	if err := validateErrorDifusion(algorithm, level); err != nil {
+		return nil, err
+	}
+	return ErrorDifusionDithering(img, string(algorithm), level), nil
+}
+
+// ErrorDifusionDitheringKeepAlphaStrict works like ErrorDifusionDitheringKeepAlpha
+// but returns an error instead of ignoring an unknown algorithm or clamping the level.
+//
+// Parameters:
+//   - img: The input image to be processed
+//   - algorithm: The dithering algorithm, one of Algorithms()
+//   - level: The number of quantization levels per channel (1-10)
+//
+// Returns:
+//   - image.Image: A new NRGBA image with the dithering effect applied
+//   - error: A *utils.ValidationError describing the first invalid parameter
+func ErrorDifusionDitheringKeepAlphaStrict(img image.Image, algorithm Algorithm, level int) (image.Image, error) {
+	if err := validateErrorDifusion(algorithm, level); err != nil {
+		return nil, err
+	}
+	return ErrorDifusionDitheringKeepAlpha(img, string(algorithm), level), nil
+}
+
+func validateErrorDifusion(algorithm Algorithm, level int) error {
+	if err := utils.ValidateOption("algorithm", algorithm, Algorithms()); err != nil {
+		return err
+	}
+	return utils.ValidateRange("level", level, 1, 10)
+}
+
+func makeDither(img *image.NRGBA, x, y int, r, g, b, a int, factor float64) {
 	bounds := img.Bounds()
 	if x >= bounds.Min.X && x < bounds.Max.X && y >= bounds.Min.Y && y < bounds.Max.Y {
-		pixel := img.RGBAAt(x, y)
+		pixel := img.NRGBAAt(x, y)
 
-		newPixel := color.RGBA{
+		newPixel := color.NRGBA{
 			R: utils.Clamp8bit(int(pixel.R) + int(float64(r)*factor)),
 			G: utils.Clamp8bit(int(pixel.G) + int(float64(g)*factor)),
 			B: utils.Clamp8bit(int(pixel.B) + int(float64(b)*factor)),
 			A: utils.Clamp8bit(int(pixel.A) + int(float64(a)*factor)),
 		}
-		img.SetRGBA(x, y, newPixel)
+		img.SetNRGBA(x, y, newPixel)
 	}
 }
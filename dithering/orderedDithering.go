@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"math"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -21,13 +22,33 @@ import (
 //     will be adjusted to the next even number.
 //
 // Returns:
-//   - A new image.Image with the ordered dithering effect applied, in RGBA64 format
+//   - A new image.Image with the ordered dithering effect applied, in NRGBA64 format
 //
-// Note: The alpha channel is also dithered by default. The function automatically
-// handles bounds checking and matrix size adjustments.
+// Note: The alpha channel is also dithered by default, use OrderedDitheringKeepAlpha
+// to leave it untouched. The function automatically handles bounds checking and
+// matrix size adjustments.
 func OrderedDithering(img image.Image, level, size int) image.Image {
+	return orderedDithering(img, level, size, false)
+}
+
+// OrderedDitheringKeepAlpha works like OrderedDithering but only quantizes the
+// colour channels, keeping the alpha of every pixel.
+//
+// Parameters:
+//   - img: The input image to be processed
+//   - level: The number of quantization levels (1 - 20)
+//   - size: The size of the dithering matrix (a power of 2)
+//
+// Returns:
+//   - A new image.Image with the ordered dithering effect applied, in NRGBA64 format
+func OrderedDitheringKeepAlpha(img image.Image, level, size int) image.Image {
+	return orderedDithering(img, level, size, true)
+}
+
+func orderedDithering(img image.Image, level, size int, keepAlpha bool) image.Image {
 	bounds := img.Bounds()
-	newImage := image.NewRGBA64(bounds)
+	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 	level = utils.ClampGeneric(level, 1, 20)
 	threshold := thresholdMatrix(size)
 
@@ -35,20 +56,25 @@ func OrderedDithering(img image.Image, level, size int) image.Image {
 		size++
 	}
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 
-			tx := x % size
-			ty := y % size
+			tx := (x - bounds.Min.X) % size
+			ty := (y - bounds.Min.Y) % size
 
 			th := threshold[tx][ty]
 
-			newImage.SetRGBA64(x, y, color.RGBA64{
+			if !keepAlpha {
+				a = uint32(orderedDither(uint64(a), level, th))
+			}
+
+			newImage.SetNRGBA64(x, y, color.NRGBA64{
 				uint16(orderedDither(uint64(r), level, th)),
 				uint16(orderedDither(uint64(g), level, th)),
 				uint16(orderedDither(uint64(b), level, th)),
-				uint16(orderedDither(uint64(a), level, th)),
+				uint16(a),
 			})
 		}
 	}
@@ -5,6 +5,7 @@ import (
 	"image/color"
 
 	"github.com/BrunoPoiano/imgeffects/blur"
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -30,17 +31,21 @@ func DifferenceOfGaussians(img image.Image, img_one_blur, img_two_blur int) imag
 
 	img_blured_one := blur.GaussianBlur(img, utils.ClampGeneric(img_one_blur, 0, 20))
 	img_blured_two := blur.GaussianBlur(img, utils.ClampGeneric(img_two_blur, 0, 20))
+	reader_one := pixel.NewReader(img_blured_one)
+	reader_two := pixel.NewReader(img_blured_two)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-			b_r, b_g, b_b, _ := img_blured_one.At(x, y).RGBA()
+			b_r, b_g, b_b, b_a := reader_one.RGBA(x, y)
+			b_r, b_g, b_b = utils.Unpremultiply(b_r, b_g, b_b, b_a)
 			b_pixel := utils.Luminance8bit(b_r, b_g, b_b)
 
-			bb_r, bb_g, bb_b, _ := img_blured_two.At(x, y).RGBA()
+			bb_r, bb_g, bb_b, bb_a := reader_two.RGBA(x, y)
+			bb_r, bb_g, bb_b = utils.Unpremultiply(bb_r, bb_g, bb_b, bb_a)
 			bb_pixel := utils.Luminance8bit(bb_r, bb_g, bb_b)
 
-			newImage.Set(x, y, color.Gray{uint8(b_pixel - bb_pixel)})
+			newImage.SetGray(x, y, color.Gray{uint8(b_pixel - bb_pixel)})
 		}
 	}
 
@@ -5,9 +5,44 @@ import (
 	"image/color"
 	"math"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
+// Kernel is a gradient kernel operator used by KernelOperatorBased.
+type Kernel string
+
+const (
+	Sobel       Kernel = "sobel"
+	Prewitt     Kernel = "prewitt"
+	RobertCross Kernel = "robert-cross"
+	Scharr      Kernel = "scharr"
+)
+
+// Kernels returns every supported kernel operator.
+//
+// Returns:
+//   - []Kernel
+func Kernels() []Kernel {
+	return []Kernel{Sobel, Prewitt, RobertCross, Scharr}
+}
+
+// ParseKernel returns the Kernel named s.
+//
+// Parameters:
+//   - s: The name of the kernel operator (case-sensitive)
+//
+// Returns:
+//   - Kernel
+//   - error: A *utils.ValidationError listing the supported kernels when s is unknown
+func ParseKernel(s string) (Kernel, error) {
+	kernel := Kernel(s)
+	if err := utils.ValidateOption("kernel", kernel, Kernels()); err != nil {
+		return "", err
+	}
+	return kernel, nil
+}
+
 // KernelOperatorBased applies edge detection using various kernel operators to detect
 // edges and boundaries in an image. It works by calculating the gradient magnitude
 // in the x and y directions using convolution with the specified kernel.
@@ -24,27 +59,17 @@ import (
 //
 // Returns:
 //   - image.Image
+//
+// An unknown kernel falls back to sobel, use KernelOperatorBasedStrict to reject it.
 func KernelOperatorBased(img image.Image, kernel string) image.Image {
 	bounds := img.Bounds()
 	newImage := image.NewGray(bounds)
+	reader := pixel.NewReader(img)
 
-	gx := [][]int{}
-	gy := [][]int{}
+	var gx, gy [][]int
 
-	switch kernel {
-	case "sobel":
-		gx = [][]int{
-			{-1, 0, 1},
-			{-2, 0, 2},
-			{-1, 0, 1},
-		}
-		gy = [][]int{
-			{-1, -2, -1},
-			{0, 0, 0},
-			{1, 2, 1},
-		}
-
-	case "prewitt":
+	switch Kernel(kernel) {
+	case Prewitt:
 		gx = [][]int{
 			{-1, 0, 1},
 			{-1, 0, 1},
@@ -55,7 +80,7 @@ func KernelOperatorBased(img image.Image, kernel string) image.Image {
 			{0, 0, 0},
 			{1, 1, 1},
 		}
-	case "robert-cross":
+	case RobertCross:
 		gx = [][]int{
 			{1, 0},
 			{0, -1},
@@ -64,7 +89,7 @@ func KernelOperatorBased(img image.Image, kernel string) image.Image {
 			{0, 1},
 			{-1, 0},
 		}
-	case "scharr":
+	case Scharr:
 		gx = [][]int{
 			{-3, 0, 3},
 			{-10, 0, 10},
@@ -75,30 +100,59 @@ func KernelOperatorBased(img image.Image, kernel string) image.Image {
 			{0, 0, 0},
 			{3, 10, 3},
 		}
+	default:
+		gx = [][]int{
+			{-1, 0, 1},
+			{-2, 0, 2},
+			{-1, 0, 1},
+		}
+		gy = [][]int{
+			{-1, -2, -1},
+			{0, 0, 0},
+			{1, 2, 1},
+		}
 	}
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
 			var sumx, sumy float64
 			kernelLen := len(gx) - 1
 			for yy := -1; yy < kernelLen; yy++ {
 				for xx := -1; xx < kernelLen; xx++ {
-					r, g, b, _ := img.At(x+xx, y+yy).RGBA()
-					pixel := utils.Luminance8bit(r, g, b)
+					r, g, b, a := reader.RGBA(x+xx, y+yy)
+					r, g, b = utils.Unpremultiply(r, g, b, a)
+					luminance := utils.Luminance8bit(r, g, b)
 
-					sumx += pixel * float64(gx[yy+1][xx+1])
-					sumy += pixel * float64(gy[yy+1][xx+1])
+					sumx += luminance * float64(gx[yy+1][xx+1])
+					sumy += luminance * float64(gy[yy+1][xx+1])
 
 				}
 			}
 
 			gradient_mag := math.Sqrt(sumx*sumx + sumy*sumy)
 
-			newImage.Set(x, y, color.Gray{uint8(gradient_mag)})
+			newImage.SetGray(x, y, color.Gray{uint8(gradient_mag)})
 		}
 	}
 
 	return newImage
 
 }
+
+// KernelOperatorBasedStrict works like KernelOperatorBased but returns an error
+// for an unknown kernel instead of falling back to sobel.
+//
+// Parameters:
+//   - img: The input image to apply edge detection to
+//   - kernel: The kernel operator, one of Kernels()
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError listing the supported kernels
+func KernelOperatorBasedStrict(img image.Image, kernel Kernel) (image.Image, error) {
+	if err := utils.ValidateOption("kernel", kernel, Kernels()); err != nil {
+		return nil, err
+	}
+	return KernelOperatorBased(img, string(kernel)), nil
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 
 	"github.com/BrunoPoiano/imgeffects/blur"
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -32,6 +33,7 @@ func LaplacianOfGaussian(img image.Image, blur_level int, scaling float64) image
 	scaling = float64(utils.ClampGeneric(int(scaling), 5, 20))
 
 	bluredImage := blur.GaussianBlur(img, blur_level)
+	reader := pixel.NewReader(bluredImage)
 
 	kernel := [][]int{
 		{0, 1, 0},
@@ -39,15 +41,16 @@ func LaplacianOfGaussian(img image.Image, blur_level int, scaling float64) image
 		{0, 1, 0},
 	}
 
-	for y := 1; y < bounds.Max.Y-1; y++ {
-		for x := 1; x < bounds.Max.X-1; x++ {
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x++ {
 
 			var sum float64
 			for ky := 0; ky < 3; ky++ {
 				for kx := 0; kx < 3; kx++ {
-					r, g, b, _ := bluredImage.At(x+kx-1, y+ky-1).RGBA()
-					pixel := utils.Luminance8bit(r, g, b)
-					sum += pixel * float64(kernel[ky][kx])
+					r, g, b, a := reader.RGBA(x+kx-1, y+ky-1)
+					r, g, b = utils.Unpremultiply(r, g, b, a)
+					luminance := utils.Luminance8bit(r, g, b)
+					sum += luminance * float64(kernel[ky][kx])
 				}
 			}
 
@@ -56,7 +59,7 @@ func LaplacianOfGaussian(img image.Image, blur_level int, scaling float64) image
 				val = 255
 			}
 
-			newImage.Set(x, y, color.Gray{uint8(val)})
+			newImage.SetGray(x, y, color.Gray{uint8(val)})
 		}
 	}
 
@@ -0,0 +1,1025 @@
+package effects
+
+import (
+	"context"
+	"image"
+	"math"
+	"math/rand/v2"
+
+	"github.com/BrunoPoiano/imgeffects/blur"
+	"github.com/BrunoPoiano/imgeffects/contrast"
+	"github.com/BrunoPoiano/imgeffects/dithering"
+	edgedetection "github.com/BrunoPoiano/imgeffects/edgeDetection"
+	"github.com/BrunoPoiano/imgeffects/filter"
+	"github.com/BrunoPoiano/imgeffects/flip"
+	"github.com/BrunoPoiano/imgeffects/hsl"
+	"github.com/BrunoPoiano/imgeffects/lines"
+	"github.com/BrunoPoiano/imgeffects/noise"
+	"github.com/BrunoPoiano/imgeffects/pointillism"
+	"github.com/BrunoPoiano/imgeffects/resize"
+	"github.com/BrunoPoiano/imgeffects/rgb"
+	"github.com/BrunoPoiano/imgeffects/threshold"
+	"github.com/BrunoPoiano/imgeffects/utils"
+	"github.com/BrunoPoiano/imgeffects/warp"
+)
+
+// run adapts an effect function that cannot fail to the Definition.Run signature.
+func run(fn func(img image.Image, v Values) image.Image) func(image.Image, Values) (image.Image, error) {
+	return func(img image.Image, v Values) (image.Image, error) {
+		return fn(img, v), nil
+	}
+}
+
+func intParam(name, description string, def, min, max int) Param {
+	return Param{Name: name, Type: Int, Description: description, Default: def, Min: float64(min), Max: float64(max)}
+}
+
+func floatParam(name, description string, def, min, max float64) Param {
+	return Param{Name: name, Type: Float, Description: description, Default: def, Min: min, Max: max}
+}
+
+func boolParam(name, description string, def bool) Param {
+	return Param{Name: name, Type: Bool, Description: description, Default: def}
+}
+
+// seedParam is the parameter of the effects using randomness.
+func seedParam() Param {
+	return intParam("seed", "random seed making the result reproducible, 0 picks a new one on every run", 0, 0, math.MaxInt32)
+}
+
+// linearParam is the parameter of the effects able to work in linear light.
+func linearParam() Param {
+	return boolParam("linear", "mix the colours in linear light instead of sRGB", false)
+}
+
+// keepAlphaParam is the option of the dithering and threshold effects leaving
+// the alpha channel untouched.
+func keepAlphaParam() Param {
+	return boolParam("keep-alpha", "leave the alpha channel untouched", false)
+}
+
+// source returns the random source for the seed parameter, nil when it is 0.
+func source(v Values) rand.Source {
+	seed := v.Int("seed")
+	if seed == 0 {
+		return nil
+	}
+	return utils.NewSource(uint64(seed))
+}
+
+func stringParam(name, description, def string, options ...string) Param {
+	return Param{Name: name, Type: String, Description: description, Default: def, Options: options}
+}
+
+// options converts the values of a string enum to parameter options.
+func options[T ~string](values []T) []string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = string(value)
+	}
+	return names
+}
+
+func init() {
+	registerBlur()
+	registerContrast()
+	registerDithering()
+	registerEdgeDetection()
+	registerFilter()
+	registerFlip()
+	registerHSL()
+	registerLines()
+	registerNoise()
+	registerPointillism()
+	registerResize()
+	registerRGB()
+	registerThreshold()
+	registerWarp()
+}
+
+func registerBlur() {
+	Register(Definition{
+		Name:        "gaussian-blur",
+		Description: "Gaussian blur using separable convolution",
+		Params:      []Param{intParam("level", "blur intensity", 5, 0, 30), linearParam()},
+		Run: run(func(img image.Image, v Values) image.Image {
+			if v.Bool("linear") {
+				return blur.GaussianBlurLinear(img, v.Int("level"))
+			}
+			return blur.GaussianBlur(img, v.Int("level"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			if v.Bool("linear") {
+				return blur.GaussianBlurLinearContext(ctx, img, v.Int("level"), progress)
+			}
+			return blur.GaussianBlurContext(ctx, img, v.Int("level"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "gaussian-blur-sigma",
+		Description: "Gaussian blur with an explicit standard deviation",
+		Params:      []Param{floatParam("sigma", "standard deviation in pixels", 2, 0, 100)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.GaussianBlurSigma(img, v.Float("sigma"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.GaussianBlurSigmaContext(ctx, img, v.Float("sigma"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "box-blur",
+		Description: "Box blur, same cost for any radius",
+		Params:      []Param{intParam("radius", "pixels averaged on each side", 5, 0, 1000)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.BoxBlur(img, v.Int("radius"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.BoxBlurContext(ctx, img, v.Int("radius"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "stack-blur",
+		Description: "Near-Gaussian stack blur, same cost for any radius",
+		Params:      []Param{intParam("radius", "distance reached by the blur in pixels", 5, 0, 1000)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.StackBlur(img, v.Int("radius"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.StackBlurContext(ctx, img, v.Int("radius"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "bilateral",
+		Description: "Edge-preserving bilateral smoothing",
+		Params: []Param{
+			floatParam("sigma-spatial", "spatial standard deviation in pixels", 3, 0.5, 50),
+			floatParam("sigma-range", "colour standard deviation in 8-bit steps", 30, 1, 255),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.Bilateral(img, v.Float("sigma-spatial"), v.Float("sigma-range"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.BilateralContext(ctx, img, v.Float("sigma-spatial"), v.Float("sigma-range"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "motion-blur",
+		Description: "Smears the image along a straight line",
+		Params: []Param{
+			floatParam("angle", "direction of the motion in degrees, 0 is horizontal", 0, -360, 360),
+			floatParam("length", "length of the motion in pixels", 10, 0, 1000),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.MotionBlur(img, v.Float("angle"), v.Float("length"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.MotionBlurContext(ctx, img, v.Float("angle"), v.Float("length"), progress)
+		},
+	})
+	centerParams := []Param{
+		floatParam("center-x", "horizontal position of the centre, 0 is the left edge and 1 the right", 0.5, 0, 1),
+		floatParam("center-y", "vertical position of the centre, 0 is the top edge and 1 the bottom", 0.5, 0, 1),
+	}
+	Register(Definition{
+		Name:        "radial-blur",
+		Description: "Spins the image around a centre",
+		Params:      append(centerParams, floatParam("angle", "angle of the spin in degrees", 10, 0, 360)),
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.RadialBlur(img, v.Float("center-x"), v.Float("center-y"), v.Float("angle"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.RadialBlurContext(ctx, img, v.Float("center-x"), v.Float("center-y"), v.Float("angle"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "zoom-blur",
+		Description: "Streaks the image towards a centre",
+		Params:      append(centerParams, floatParam("strength", "part of the distance to the centre covered", 0.2, 0, 1)),
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.ZoomBlur(img, v.Float("center-x"), v.Float("center-y"), v.Float("strength"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.ZoomBlurContext(ctx, img, v.Float("center-x"), v.Float("center-y"), v.Float("strength"), progress)
+		},
+	})
+	bokehOptions := func(v Values) blur.BokehOptions {
+		return blur.BokehOptions{
+			Radius:    v.Int("radius"),
+			Aperture:  blur.Aperture(v.String("aperture")),
+			Rotation:  v.Float("rotation"),
+			Threshold: v.Float("threshold"),
+			Boost:     v.Float("boost"),
+		}
+	}
+	Register(Definition{
+		Name:        "bokeh",
+		Description: "Out of focus lens blur with disk or hexagon highlights",
+		Params: []Param{
+			intParam("radius", "radius of the aperture in pixels", 6, 0, 200),
+			stringParam("aperture", "shape of the aperture", string(blur.Disk), options(blur.Apertures())...),
+			floatParam("rotation", "clockwise rotation of the hexagon in degrees", 0, -360, 360),
+			floatParam("threshold", "luminance above which highlights are boosted", 0.8, 0, 1),
+			floatParam("boost", "extra brightness of the highlights", 2, 0, 100),
+		},
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			return blur.BokehStrict(img, bokehOptions(v))
+		},
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.BokehStrictContext(ctx, img, bokehOptions(v), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "tilt-shift",
+		Description: "Miniature effect, blurring above and below a sharp band",
+		Params: []Param{
+			floatParam("center", "vertical position of the sharp band, 0 is the top and 1 the bottom", 0.5, 0, 1),
+			floatParam("size", "height of the sharp band, fraction of the image height", 0.2, 0, 1),
+			floatParam("feather", "height of the transition to the full blur, fraction of the image height", 0.2, 0, 1),
+			intParam("level", "blur intensity", 10, 0, 30),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.TiltShift(img, v.Float("center"), v.Float("size"), v.Float("feather"), v.Int("level"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.TiltShiftContext(ctx, img, v.Float("center"), v.Float("size"), v.Float("feather"), v.Int("level"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "median",
+		Description: "Median filter, removes noise while preserving edges",
+		Params:      []Param{intParam("box", "size of the filter window", 3, 3, 201)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.Median(img, v.Int("box"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.MedianContext(ctx, img, v.Int("box"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "percentile",
+		Description: "Rank filter, from a minimum (0) through the median (50) to a maximum (100)",
+		Params: []Param{
+			intParam("radius", "pixels covered on each side", 2, 0, 100),
+			floatParam("percentile", "rank kept in the window", 50, 0, 100),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return blur.Percentile(img, v.Int("radius"), v.Float("percentile"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return blur.PercentileContext(ctx, img, v.Int("radius"), v.Float("percentile"), progress)
+		},
+	})
+}
+
+func registerContrast() {
+	Register(Definition{
+		Name:        "logarithmic",
+		Description: "Logarithmic transformation, enhances dark or bright regions",
+		Params:      []Param{floatParam("variation", "strength and direction of the transformation", 0.5, -1, 1)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return contrast.LogarithmicTransformation(img, v.Float("variation"))
+		}),
+	})
+	Register(Definition{
+		Name:        "unsharp-masking",
+		Description: "Sharpens the image by subtracting a blurred copy",
+		Params: []Param{
+			floatParam("variation", "sharpening intensity", 0.5, 0, 1),
+			intParam("blur", "radius of the gaussian blur", 5, 1, 20),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return contrast.UnsharpMasking(img, v.Float("variation"), v.Int("blur"))
+		}),
+	})
+	Register(Definition{
+		Name:        "linear-contrast-stretching",
+		Description: "Stretches each channel to the full intensity range",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return contrast.LinearContrastStretching(img)
+		}),
+	})
+	Register(Definition{
+		Name:        "linear-contrast-stretching-grayscale",
+		Description: "Stretches the intensity range and converts to grayscale",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return contrast.LinearContrastStretchingGrayscale(img)
+		}),
+	})
+	Register(Definition{
+		Name:        "histogram-equalization",
+		Description: "Spreads the luminance over the whole range, keeping the hue",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return contrast.HistogramEqualization(img)
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return contrast.HistogramEqualizationContext(ctx, img, progress)
+		},
+	})
+	Register(Definition{
+		Name:        "clahe",
+		Description: "Contrast limited adaptive histogram equalization of the luminance",
+		Params: []Param{
+			intParam("tiles-x", "number of tiles across", 8, 1, 64),
+			intParam("tiles-y", "number of tiles down", 8, 1, 64),
+			floatParam("clip-limit", "maximum count of a level relative to the average", 2, 1, 100),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return contrast.CLAHE(img, v.Int("tiles-x"), v.Int("tiles-y"), v.Float("clip-limit"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return contrast.CLAHEContext(ctx, img, v.Int("tiles-x"), v.Int("tiles-y"), v.Float("clip-limit"), progress)
+		},
+	})
+}
+
+func registerDithering() {
+	Register(Definition{
+		Name:        "dither",
+		Description: "Error diffusion dithering",
+		Params: []Param{
+			stringParam("algorithm", "error diffusion algorithm", string(dithering.FloydSteinberg),
+				options(dithering.Algorithms())...),
+			intParam("level", "quantization levels per channel", 2, 1, 10),
+			keepAlphaParam(),
+		},
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			if v.Bool("keep-alpha") {
+				return dithering.ErrorDifusionDitheringKeepAlphaStrict(img, dithering.Algorithm(v.String("algorithm")), v.Int("level"))
+			}
+			return dithering.ErrorDifusionDitheringStrict(img, dithering.Algorithm(v.String("algorithm")), v.Int("level"))
+		},
+	})
+	Register(Definition{
+		Name:        "ordered-dither",
+		Description: "Ordered dithering using a Bayer matrix",
+		Params: []Param{
+			intParam("level", "quantization levels", 2, 1, 20),
+			intParam("size", "size of the Bayer matrix (power of 2)", 4, 2, 64),
+			keepAlphaParam(),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			if v.Bool("keep-alpha") {
+				return dithering.OrderedDitheringKeepAlpha(img, v.Int("level"), v.Int("size"))
+			}
+			return dithering.OrderedDithering(img, v.Int("level"), v.Int("size"))
+		}),
+	})
+}
+
+func registerEdgeDetection() {
+	Register(Definition{
+		Name:        "difference-of-gaussians",
+		Description: "Edge detection subtracting two blurred copies",
+		Params: []Param{
+			intParam("blur-one", "blur intensity of the first gaussian", 1, 0, 20),
+			intParam("blur-two", "blur intensity of the second gaussian", 5, 0, 20),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return edgedetection.DifferenceOfGaussians(img, v.Int("blur-one"), v.Int("blur-two"))
+		}),
+	})
+	Register(Definition{
+		Name:        "laplacian-of-gaussian",
+		Description: "Edge detection with a laplacian kernel over a blurred copy",
+		Params: []Param{
+			intParam("blur", "blur intensity", 3, 0, 20),
+			floatParam("scaling", "edge intensity amplification", 10, 5, 20),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return edgedetection.LaplacianOfGaussian(img, v.Int("blur"), v.Float("scaling"))
+		}),
+	})
+	Register(Definition{
+		Name:        "kernel-operator",
+		Description: "Edge detection using a gradient kernel operator",
+		Params: []Param{
+			stringParam("kernel", "kernel operator", string(edgedetection.Sobel), options(edgedetection.Kernels())...),
+		},
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			return edgedetection.KernelOperatorBasedStrict(img, edgedetection.Kernel(v.String("kernel")))
+		},
+	})
+}
+
+func registerFilter() {
+	Register(Definition{
+		Name:        "voronoi-pixelation",
+		Description: "Mosaic of voronoi cells filled with a sampled colour",
+		Params:      []Param{intParam("seeds", "number of voronoi cells", 500, 1, 100000), seedParam()},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return filter.VoronoiPixelationWithSource(img, v.Int("seeds"), source(v))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return filter.VoronoiPixelationWithSourceContext(ctx, img, v.Int("seeds"), source(v), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "solarize",
+		Description: "Inverts pixels brighter than a threshold",
+		Params:      []Param{intParam("level", "luminance threshold percentage", 50, 1, 100)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return filter.SolarizeEffect(img, v.Int("level"))
+		}),
+	})
+	Register(Definition{
+		Name:        "chromatic-aberration",
+		Description: "Shifts the red and blue channels in opposite directions",
+		Params: []Param{
+			intParam("x-offset", "horizontal channel offset", 5, 1, 20),
+			intParam("y-offset", "vertical channel offset", 5, 1, 20),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return filter.ChromaticAberration(img, v.Int("x-offset"), v.Int("y-offset"))
+		}),
+	})
+	Register(Definition{
+		Name:        "gamma-correction",
+		Description: "Raises each channel to the power of gamma",
+		Params:      []Param{floatParam("gamma", "gamma factor, negative values use 1/-gamma", 2, -10, 10)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return filter.GammaCorrection(img, v.Float("gamma"))
+		}),
+	})
+	Register(Definition{
+		Name:        "kuwahara",
+		Description: "Edge preserving smoothing filter",
+		Params:      []Param{intParam("size", "filter window size", 5, 1, 30)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return filter.KuwaharaFilter(img, v.Int("size"))
+		}),
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return filter.KuwaharaFilterContext(ctx, img, v.Int("size"), progress)
+		},
+	})
+	Register(Definition{
+		Name:        "grayscale",
+		Description: "Converts to 8-bit grayscale",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return filter.GrayScale(img)
+		}),
+	})
+	Register(Definition{
+		Name:        "grayscale16",
+		Description: "Converts to 16-bit grayscale",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return filter.GrayScale16(img)
+		}),
+	})
+	Register(Definition{
+		Name:        "invert",
+		Description: "Photographic negative",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return filter.Invert(img)
+		}),
+	})
+}
+
+func registerFlip() {
+	Register(Definition{
+		Name:        "flip-horizontal",
+		Description: "Mirrors the image along the vertical axis",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return flip.FlipHorizontal(img)
+		}),
+	})
+	Register(Definition{
+		Name:        "flip-vertical",
+		Description: "Mirrors the image along the horizontal axis",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return flip.FlipVertical(img)
+		}),
+	})
+
+	for _, def := range []struct {
+		name, description string
+		transform         func(image.Image) image.Image
+	}{
+		{"rotate-90", "Rotates the image 90 degrees clockwise", flip.Rotate90},
+		{"rotate-180", "Rotates the image 180 degrees", flip.Rotate180},
+		{"rotate-270", "Rotates the image 90 degrees counter-clockwise", flip.Rotate270},
+		{"transpose", "Mirrors the image along its main diagonal", flip.Transpose},
+		{"transverse", "Mirrors the image along its anti-diagonal", flip.Transverse},
+	} {
+		Register(Definition{
+			Name:        def.name,
+			Description: def.description,
+			Run: run(func(img image.Image, v Values) image.Image {
+				return def.transform(img)
+			}),
+		})
+	}
+
+	transformOptions := func(v Values) (flip.TransformOptions, error) {
+		background, err := utils.ParseHexColor(v.String("background"))
+		if err != nil {
+			return flip.TransformOptions{}, err
+		}
+		return flip.TransformOptions{
+			Filter:     resize.Filter(v.String("filter")),
+			Background: background,
+		}, nil
+	}
+	transformParams := []Param{
+		stringParam("filter", "resampling filter", string(resize.Bilinear), options(resize.Filters())...),
+		stringParam("background", "colour of the uncovered areas (#rrggbbaa)", "#00000000"),
+	}
+
+	Register(Definition{
+		Name:        "rotate",
+		Description: "Rotates the image by an arbitrary angle around its centre",
+		Params: append([]Param{
+			floatParam("angle", "clockwise rotation in degrees", 30, -360, 360),
+			boolParam("expand", "grow the canvas to hold the whole rotated image", true),
+		}, transformParams...),
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			opts, err := transformOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			return flip.RotateStrict(img, v.Float("angle"), v.Bool("expand"), opts)
+		},
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			opts, err := transformOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			return flip.RotateStrictContext(ctx, img, v.Float("angle"), v.Bool("expand"), opts, progress)
+		},
+	})
+
+	affine := func(img image.Image, v Values) (flip.Matrix, int, int) {
+		m := flip.Scale(v.Float("scale-x"), v.Float("scale-y")).
+			Then(flip.Shear(v.Float("shear-x"), v.Float("shear-y"))).
+			Then(flip.Translate(v.Float("translate-x"), v.Float("translate-y")))
+		return m, img.Bounds().Dx(), img.Bounds().Dy()
+	}
+	Register(Definition{
+		Name:        "affine",
+		Description: "Scales, shears and translates the image, keeping its size",
+		Params: append([]Param{
+			floatParam("scale-x", "horizontal scale", 1, -10, 10),
+			floatParam("scale-y", "vertical scale", 1, -10, 10),
+			floatParam("shear-x", "horizontal shear, in pixels per row", 0, -10, 10),
+			floatParam("shear-y", "vertical shear, in pixels per column", 0, -10, 10),
+			floatParam("translate-x", "horizontal translation in pixels", 0, -65535, 65535),
+			floatParam("translate-y", "vertical translation in pixels", 0, -65535, 65535),
+		}, transformParams...),
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			opts, err := transformOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			m, width, height := affine(img, v)
+			return flip.AffineStrict(img, m, width, height, opts)
+		},
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			opts, err := transformOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			m, width, height := affine(img, v)
+			return flip.AffineStrictContext(ctx, img, m, width, height, opts, progress)
+		},
+	})
+}
+
+func registerHSL() {
+	Register(Definition{
+		Name:        "hue",
+		Description: "Shifts the hue angle",
+		Params:      []Param{intParam("change", "hue shift in degrees", 180, 0, 360)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return hsl.Hue(img, v.Int("change"))
+		}),
+	})
+	Register(Definition{
+		Name:        "saturation",
+		Description: "Adjusts the colour saturation",
+		Params:      []Param{floatParam("change", "saturation adjustment", 0.5, -1, 1)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return hsl.Saturation(img, v.Float("change"))
+		}),
+	})
+	Register(Definition{
+		Name:        "luminance",
+		Description: "Adjusts the brightness",
+		Params:      []Param{floatParam("change", "luminance adjustment", 0.5, -1, 1)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return hsl.Luminance(img, v.Float("change"))
+		}),
+	})
+}
+
+func registerLines() {
+	params := []Param{
+		intParam("size", "size of the sampling grid", 8, 1, 20),
+		boolParam("color", "use the image colours instead of black", false),
+	}
+
+	Register(Definition{
+		Name:        "lines-horizontal",
+		Description: "Horizontal lines sized by brightness",
+		Params:      params,
+		Run: run(func(img image.Image, v Values) image.Image {
+			return lines.LinesHorizontal(img, v.Int("size"), v.Bool("color"))
+		}),
+	})
+	Register(Definition{
+		Name:        "lines-vertical",
+		Description: "Vertical lines sized by brightness",
+		Params:      params,
+		Run: run(func(img image.Image, v Values) image.Image {
+			return lines.LinesVertical(img, v.Int("size"), v.Bool("color"))
+		}),
+	})
+	Register(Definition{
+		Name:        "lines-diagonal",
+		Description: "Diagonal lines sized by brightness",
+		Params:      params,
+		Run: run(func(img image.Image, v Values) image.Image {
+			return lines.LinesDiagonal(img, v.Int("size"), v.Bool("color"))
+		}),
+	})
+}
+
+// translate moves an image generated at the origin to min, so the generated
+// images keep the bounds of the input like the other effects.
+func translate(img image.Image, min image.Point) image.Image {
+	switch m := img.(type) {
+	case *image.RGBA64:
+		m.Rect = m.Rect.Add(min)
+	case *image.Gray:
+		m.Rect = m.Rect.Add(min)
+	}
+	return img
+}
+
+func registerNoise() {
+	Register(Definition{
+		Name:        "blending-noise",
+		Description: "Blends the image with a generated noise pattern",
+		Params: []Param{
+			floatParam("alpha", "amount of the original image preserved", 0.8, 0, 1),
+			stringParam("type", "type of noise", "default", "default", "gray", "color"),
+			seedParam(),
+			linearParam(),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			if v.Bool("linear") {
+				return noise.BlendingNoiseToImageLinear(img, v.Float("alpha"), v.String("type"), source(v))
+			}
+			return noise.BlendingNoiseToImageWithSource(img, v.Float("alpha"), v.String("type"), source(v))
+		}),
+	})
+	Register(Definition{
+		Name:        "noise",
+		Description: "Black and white noise the size of the image",
+		Params:      []Param{seedParam()},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return translate(noise.NoiseGeneratorWithSource(img.Bounds().Dx(), img.Bounds().Dy(), source(v)), img.Bounds().Min)
+		}),
+	})
+	Register(Definition{
+		Name:        "noise-color",
+		Description: "Colour noise the size of the image",
+		Params:      []Param{seedParam()},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return translate(noise.NoiseGeneratorColorWithSource(img.Bounds().Dx(), img.Bounds().Dy(), source(v)), img.Bounds().Min)
+		}),
+	})
+	Register(Definition{
+		Name:        "noise-grayscale",
+		Description: "Grayscale noise the size of the image",
+		Params:      []Param{seedParam()},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return translate(noise.NoiseGeneratorGrayScaleWithSource(img.Bounds().Dx(), img.Bounds().Dy(), source(v)), img.Bounds().Min)
+		}),
+	})
+}
+
+func registerPointillism() {
+	params := []Param{
+		intParam("size", "size of each halftone cell", 8, 1, 20),
+		boolParam("color", "use the image colours instead of black", false),
+	}
+
+	Register(Definition{
+		Name:        "halftone",
+		Description: "Halftone dots sized by brightness",
+		Params:      params,
+		Run: run(func(img image.Image, v Values) image.Image {
+			return pointillism.Halftone(img, v.Int("size"), v.Bool("color"))
+		}),
+	})
+	Register(Definition{
+		Name:        "halftone-diagonal",
+		Description: "Halftone dots with a diagonal offset",
+		Params:      params,
+		Run: run(func(img image.Image, v Values) image.Image {
+			return pointillism.HalftoneDiagonal(img, v.Int("size"), v.Bool("color"))
+		}),
+	})
+	Register(Definition{
+		Name:        "pointillism-grid",
+		Description: "Grid of points filled with the average colour",
+		Params:      []Param{intParam("radius", "radius of the points", 5, 1, 20)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return pointillism.PointillismGridBased(img, v.Int("radius"))
+		}),
+	})
+	Register(Definition{
+		Name:        "pointillism-luminance",
+		Description: "Randomly placed points sized by luminance",
+		Params: []Param{
+			intParam("points", "number of points", 50000, 10, 100000000),
+			intParam("scaling", "maximum point radius", 5, 1, 30),
+			seedParam(),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return pointillism.PointillismLuminanceBasedWithSource(img, v.Int("points"), v.Int("scaling"), source(v))
+		}),
+	})
+	Register(Definition{
+		Name:        "pointillism-luminance-grid",
+		Description: "Grid of points sized by luminance",
+		Params: []Param{
+			intParam("scaling", "maximum point radius", 10, 1, 100),
+			stringParam("direction", "traversal direction", string(pointillism.Up), options(pointillism.Directions())...),
+			seedParam(),
+		},
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			return pointillism.PointillismLuminanceGridBasedStrictWithSource(img, v.Int("scaling"), pointillism.Direction(v.String("direction")), source(v))
+		},
+	})
+}
+
+func registerResize() {
+	params := []Param{
+		intParam("width", "new width in pixels", 256, 1, 65535),
+		intParam("height", "new height in pixels", 256, 1, 65535),
+	}
+
+	Register(Definition{
+		Name:        "nearest-neighbor",
+		Description: "Resize using nearest neighbor interpolation",
+		Params:      params,
+		Run: run(func(img image.Image, v Values) image.Image {
+			return resize.NearestNeighbor(img, v.Int("width"), v.Int("height"))
+		}),
+	})
+	Register(Definition{
+		Name:        "bypolar-interpolate",
+		Description: "Resize interpolating the four nearest pixels",
+		Params:      append(params, linearParam()),
+		Run: run(func(img image.Image, v Values) image.Image {
+			if v.Bool("linear") {
+				return resize.BypolarInterpolateLinear(img, v.Int("width"), v.Int("height"))
+			}
+			return resize.BypolarInterpolate(img, v.Int("width"), v.Int("height"))
+		}),
+	})
+	Register(Definition{
+		Name:        "resample",
+		Description: "Resize with a separable filter, antialiased when downscaling",
+		Params: append(params,
+			stringParam("filter", "resampling filter", string(resize.Lanczos3), options(resize.Filters())...),
+			linearParam(),
+		),
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			if v.Bool("linear") {
+				return resize.ResampleLinear(img, v.Int("width"), v.Int("height"), resize.Filter(v.String("filter"))), nil
+			}
+			return resize.ResampleStrict(img, v.Int("width"), v.Int("height"), resize.Filter(v.String("filter")))
+		},
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			if v.Bool("linear") {
+				return resize.ResampleLinearContext(ctx, img, v.Int("width"), v.Int("height"), resize.Filter(v.String("filter")), progress)
+			}
+			return resize.ResampleStrictContext(ctx, img, v.Int("width"), v.Int("height"), resize.Filter(v.String("filter")), progress)
+		},
+	})
+
+	thumbnailOptions := func(v Values) (resize.ThumbnailOptions, error) {
+		background, err := utils.ParseHexColor(v.String("background"))
+		if err != nil {
+			return resize.ThumbnailOptions{}, err
+		}
+		return resize.ThumbnailOptions{
+			Width:      v.Int("width"),
+			Height:     v.Int("height"),
+			MaxWidth:   v.Int("max-width"),
+			MaxHeight:  v.Int("max-height"),
+			Mode:       resize.Mode(v.String("mode")),
+			Anchor:     resize.Anchor(v.String("anchor")),
+			Filter:     resize.Filter(v.String("filter")),
+			Background: background,
+			NoUpscale:  v.Bool("no-upscale"),
+		}, nil
+	}
+	Register(Definition{
+		Name:        "thumbnail",
+		Description: "Thumbnail fitting, filling, stretching or padding to a size",
+		Params: []Param{
+			intParam("width", "thumbnail width in pixels, 0 keeps the aspect ratio", 64, 0, 65535),
+			intParam("height", "thumbnail height in pixels, 0 keeps the aspect ratio", 64, 0, 65535),
+			intParam("max-width", "largest thumbnail width in every mode, 0 means no limit", 0, 0, 65535),
+			intParam("max-height", "largest thumbnail height in every mode, 0 means no limit", 0, 0, 65535),
+			stringParam("mode", "how the image fits the size", string(resize.Fit), options(resize.Modes())...),
+			stringParam("anchor", "part kept by fill and position with pad", string(resize.Center), options(resize.Anchors())...),
+			stringParam("filter", "resampling filter", string(resize.Lanczos3), options(resize.Filters())...),
+			stringParam("background", "canvas colour of pad (#rrggbbaa)", "#00000000"),
+			boolParam("no-upscale", "never enlarge the image", false),
+		},
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			opts, err := thumbnailOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			return resize.ThumbnailStrict(img, opts)
+		},
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			opts, err := thumbnailOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			return resize.ThumbnailStrictContext(ctx, img, opts, progress)
+		},
+	})
+	Register(Definition{
+		Name:        "seam-carving",
+		Description: "Content-aware resize removing or inserting low energy seams",
+		Params: []Param{
+			intParam("width", "new width in pixels", 64, 1, 65535),
+			intParam("height", "new height in pixels", 64, 1, 65535),
+		},
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			return resize.SeamCarvingStrict(img, v.Int("width"), v.Int("height"), nil, nil)
+		},
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			return resize.SeamCarvingStrictContext(ctx, img, v.Int("width"), v.Int("height"), nil, nil, progress)
+		},
+	})
+}
+
+func registerRGB() {
+	Register(Definition{
+		Name:        "adjust-levels",
+		Description: "Scales the intensity of each colour channel",
+		Params: []Param{
+			intParam("red", "red intensity percentage", 100, 1, 100),
+			intParam("green", "green intensity percentage", 100, 1, 100),
+			intParam("blue", "blue intensity percentage", 100, 1, 100),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return rgb.AdjustLevels(img, v.Int("red"), v.Int("green"), v.Int("blue"))
+		}),
+	})
+}
+
+func registerThreshold() {
+	Register(Definition{
+		Name:        "global-threshold",
+		Description: "Black and white binary threshold on luminance",
+		Params:      []Param{intParam("level", "threshold percentage", 50, 1, 100), keepAlphaParam()},
+		Run: run(func(img image.Image, v Values) image.Image {
+			if v.Bool("keep-alpha") {
+				return threshold.GlobalThresholdKeepAlpha(img, v.Int("level"))
+			}
+			return threshold.GlobalThreshold(img, v.Int("level"))
+		}),
+	})
+	Register(Definition{
+		Name:        "global-threshold-color",
+		Description: "Binary threshold applied to each colour channel",
+		Params:      []Param{intParam("level", "threshold percentage", 50, 1, 100)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return threshold.GlobalThresholdColor(img, v.Int("level"))
+		}),
+	})
+	Register(Definition{
+		Name:        "multi-threshold",
+		Description: "Quantized grayscale bands",
+		Params:      []Param{intParam("quantity", "number of thresholds", 4, 2, 100), keepAlphaParam()},
+		Run: run(func(img image.Image, v Values) image.Image {
+			if v.Bool("keep-alpha") {
+				return threshold.MultiThresholdKeepAlpha(img, v.Int("quantity"))
+			}
+			return threshold.MultiThreshold(img, v.Int("quantity"))
+		}),
+	})
+	Register(Definition{
+		Name:        "multi-threshold-color",
+		Description: "Quantized colour bands on each channel",
+		Params:      []Param{intParam("quantity", "number of thresholds", 4, 2, 10)},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return threshold.MultiThresholdColor(img, v.Int("quantity"))
+		}),
+	})
+	Register(Definition{
+		Name:        "threshold-rgb",
+		Description: "Keeps only the dominant colour channel of each pixel",
+		Run: run(func(img image.Image, v Values) image.Image {
+			return threshold.ThresholdRGB(img)
+		}),
+	})
+
+	channels := []string{"red", "green", "blue", "original"}
+	Register(Definition{
+		Name:        "multi-threshold-rgb",
+		Description: "Luminance bands mapped to a single colour channel",
+		Params: []Param{
+			stringParam("c1", "channel kept for the brightest band", "red", channels...),
+			stringParam("c2", "channel kept for the middle band", "green", channels...),
+			stringParam("c3", "channel kept for the darkest band", "blue", channels...),
+		},
+		Run: run(func(img image.Image, v Values) image.Image {
+			return threshold.MultiThresholdRGB(img, v.String("c1"), v.String("c2"), v.String("c3"))
+		}),
+	})
+}
+
+func registerWarp() {
+	warpOptions := func(v Values) (warp.Options, error) {
+		background, err := utils.ParseHexColor(v.String("background"))
+		if err != nil {
+			return warp.Options{}, err
+		}
+		return warp.Options{Filter: resize.Filter(v.String("filter")), Background: background}, nil
+	}
+	warpParams := []Param{
+		stringParam("filter", "resampling filter", string(resize.Bilinear), options(resize.Filters())...),
+		stringParam("background", "colour of the uncovered areas (#rrggbbaa)", "#00000000"),
+	}
+
+	// The corners are given in percentages of the image size, and a zero
+	// width or height keeps the one of the image.
+	perspective := func(img image.Image, v Values) ([4]warp.Point, int, int) {
+		w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+		var corners [4]warp.Point
+		for i, name := range []string{"top-left", "top-right", "bottom-right", "bottom-left"} {
+			corners[i] = warp.Point{X: v.Float(name+"-x") * w / 100, Y: v.Float(name+"-y") * h / 100}
+		}
+		width, height := v.Int("width"), v.Int("height")
+		if width == 0 {
+			width = img.Bounds().Dx()
+		}
+		if height == 0 {
+			height = img.Bounds().Dy()
+		}
+		return corners, width, height
+	}
+	Register(Definition{
+		Name:        "perspective",
+		Description: "Straightens a quadrilateral area into a rectangle",
+		Params: append([]Param{
+			floatParam("top-left-x", "top left corner, percentage of the width", 10, -100, 200),
+			floatParam("top-left-y", "top left corner, percentage of the height", 0, -100, 200),
+			floatParam("top-right-x", "top right corner, percentage of the width", 90, -100, 200),
+			floatParam("top-right-y", "top right corner, percentage of the height", 0, -100, 200),
+			floatParam("bottom-right-x", "bottom right corner, percentage of the width", 100, -100, 200),
+			floatParam("bottom-right-y", "bottom right corner, percentage of the height", 100, -100, 200),
+			floatParam("bottom-left-x", "bottom left corner, percentage of the width", 0, -100, 200),
+			floatParam("bottom-left-y", "bottom left corner, percentage of the height", 100, -100, 200),
+			intParam("width", "result width in pixels, 0 keeps the image width", 0, 0, 65535),
+			intParam("height", "result height in pixels, 0 keeps the image height", 0, 0, 65535),
+		}, warpParams...),
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			opts, err := warpOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			corners, width, height := perspective(img, v)
+			return warp.PerspectiveStrict(img, corners, width, height, opts)
+		},
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			opts, err := warpOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			corners, width, height := perspective(img, v)
+			return warp.PerspectiveStrictContext(ctx, img, corners, width, height, opts, progress)
+		},
+	})
+	Register(Definition{
+		Name:        "lens-distortion",
+		Description: "Corrects or applies barrel and pincushion distortion",
+		Params: append([]Param{
+			floatParam("k1", "quadratic coefficient, negative corrects barrel distortion", -0.2, -1, 1),
+			floatParam("k2", "quartic coefficient, refining the corners", 0, -1, 1),
+		}, warpParams...),
+		Run: func(img image.Image, v Values) (image.Image, error) {
+			opts, err := warpOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			return warp.LensDistortionStrict(img, v.Float("k1"), v.Float("k2"), opts)
+		},
+		RunContext: func(ctx context.Context, img image.Image, v Values, progress utils.ProgressFunc) (image.Image, error) {
+			opts, err := warpOptions(v)
+			if err != nil {
+				return nil, err
+			}
+			return warp.LensDistortionStrictContext(ctx, img, v.Float("k1"), v.Float("k2"), opts, progress)
+		},
+	})
+}
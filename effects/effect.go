@@ -0,0 +1,312 @@
+package effects
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/BrunoPoiano/imgeffects/mask"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// ParamType identifies the kind of value an effect parameter accepts.
+type ParamType int
+
+const (
+	Int ParamType = iota
+	Float
+	Bool
+	String
+)
+
+// String returns the lower case name of the parameter type.
+func (t ParamType) String() string {
+	switch t {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	case String:
+		return "string"
+	default:
+		return fmt.Sprintf("ParamType(%d)", int(t))
+	}
+}
+
+// MarshalText encodes the parameter type as its name, so effect listings
+// read naturally when serialised to JSON.
+func (t ParamType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Param describes a single parameter of an effect.
+//
+// Fields:
+//   - Name: The parameter name used in Values, recipes and command-line flags
+//   - Type: The kind of value accepted
+//   - Description: A short human readable description
+//   - Default: The value used when the parameter is not provided
+//   - Min, Max: The accepted range for Int and Float parameters
+//   - Options: The accepted values for String parameters (empty accepts anything)
+type Param struct {
+	Name        string    `json:"name"`
+	Type        ParamType `json:"type"`
+	Description string    `json:"description,omitempty"`
+	Default     any       `json:"default"`
+	Min         float64   `json:"min,omitempty"`
+	Max         float64   `json:"max,omitempty"`
+	Options     []string  `json:"options,omitempty"`
+}
+
+// Values holds the parameter values of an effect, keyed by parameter name.
+type Values map[string]any
+
+// Int returns the named parameter as an int.
+func (v Values) Int(name string) int {
+	i, _ := v[name].(int)
+	return i
+}
+
+// Float returns the named parameter as a float64.
+func (v Values) Float(name string) float64 {
+	f, _ := v[name].(float64)
+	return f
+}
+
+// Bool returns the named parameter as a bool.
+func (v Values) Bool(name string) bool {
+	b, _ := v[name].(bool)
+	return b
+}
+
+// String returns the named parameter as a string.
+func (v Values) String(name string) string {
+	s, _ := v[name].(string)
+	return s
+}
+
+// Effect is an image effect configured with its parameters and ready to be applied.
+type Effect interface {
+	// Name returns the registered name of the effect.
+	Name() string
+	// Params describes the parameters the effect accepts.
+	Params() []Param
+	// Values returns the parameter values the effect was configured with.
+	Values() Values
+	// Apply runs the effect on img and returns the resulting image.
+	Apply(img image.Image) (image.Image, error)
+	// ApplyContext runs the effect on img, aborting when ctx is cancelled.
+	// progress, which may be nil, receives the completed and total units of work.
+	ApplyContext(ctx context.Context, img image.Image, progress utils.ProgressFunc) (image.Image, error)
+}
+
+// Definition describes an effect that can be registered and instantiated by name.
+//
+// Fields:
+//   - Name: Unique name of the effect (e.g. "gaussian-blur")
+//   - Description: A short human readable description
+//   - Params: The parameters accepted by the effect
+//   - Run: The function applying the effect with already validated values
+//   - RunContext: Optional cancellable variant of Run for long running effects.
+//     Effects without it are only checked for cancellation before and after running.
+type Definition struct {
+	Name        string
+	Description string
+	Params      []Param
+	Run         func(img image.Image, values Values) (image.Image, error)
+	RunContext  func(ctx context.Context, img image.Image, values Values, progress utils.ProgressFunc) (image.Image, error)
+}
+
+// New validates values against the definition parameters and returns a configured Effect.
+// Missing parameters take their default value.
+//
+// Parameters:
+//   - values: The parameter values, may be nil
+//
+// Returns:
+//   - Effect: The configured effect
+//   - error: When a parameter is unknown or has a value of the wrong type, or a
+//     wrapped *utils.ValidationError when a value is out of range or not one of the options
+func (d Definition) New(values Values) (Effect, error) {
+	normalized := make(Values, len(d.Params))
+
+	for name := range values {
+		if _, ok := d.param(name); !ok {
+			return nil, fmt.Errorf("effects: %s: unknown parameter %q", d.Name, name)
+		}
+	}
+
+	for _, p := range d.Params {
+		value, ok := values[p.Name]
+		if !ok {
+			value = p.Default
+		}
+
+		v, err := normalize(p, value)
+		if err == nil {
+			err = validate(p, v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("effects: %s: %w", d.Name, err)
+		}
+		normalized[p.Name] = v
+	}
+
+	return &effect{def: d, values: normalized}, nil
+}
+
+func (d Definition) param(name string) (Param, bool) {
+	for _, p := range d.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Param{}, false
+}
+
+// normalize converts value to the canonical Go type of the parameter
+// (int, float64, bool or string). Numbers decoded from JSON arrive as float64
+// or json.Number and are accepted for Int parameters when they are integral.
+func normalize(p Param, value any) (any, error) {
+	switch p.Type {
+	case Int:
+		switch n := value.(type) {
+		case int:
+			return n, nil
+		case int64:
+			return int(n), nil
+		case float64:
+			if n == math.Trunc(n) {
+				return int(n), nil
+			}
+		case json.Number:
+			if i, err := n.Int64(); err == nil {
+				return int(i), nil
+			}
+		}
+	case Float:
+		switch n := value.(type) {
+		case float64:
+			return n, nil
+		case float32:
+			return float64(n), nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case json.Number:
+			if f, err := n.Float64(); err == nil {
+				return f, nil
+			}
+		}
+	case Bool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case String:
+		if s, ok := value.(string); ok {
+			if len(p.Options) == 0 {
+				return s, nil
+			}
+			return s, utils.ValidateOption(p.Name, s, p.Options)
+		}
+	}
+
+	return nil, fmt.Errorf("parameter %q: expected %s, got %v (%T)", p.Name, p.Type, value, value)
+}
+
+// validate checks a normalized value against the range of the parameter.
+// Parameters without a range (Min and Max both 0) accept any value.
+func validate(p Param, value any) error {
+	if p.Min == 0 && p.Max == 0 {
+		return nil
+	}
+
+	var f float64
+	switch v := value.(type) {
+	case int:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return nil
+	}
+
+	if f < p.Min || f > p.Max {
+		return &utils.ValidationError{Param: p.Name, Value: value, Min: p.Min, Max: p.Max}
+	}
+	return nil
+}
+
+type effect struct {
+	def    Definition
+	values Values
+}
+
+func (e *effect) Name() string    { return e.def.Name }
+func (e *effect) Params() []Param { return e.def.Params }
+func (e *effect) Values() Values  { return e.values }
+
+func (e *effect) Apply(img image.Image) (image.Image, error) {
+	return e.def.Run(img, e.values)
+}
+
+func (e *effect) ApplyContext(ctx context.Context, img image.Image, progress utils.ProgressFunc) (image.Image, error) {
+	if e.def.RunContext != nil {
+		return e.def.RunContext(ctx, img, e.values, progress)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	newImage, err := e.def.Run(img, e.values)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if progress != nil {
+		progress(1, 1)
+	}
+	return newImage, nil
+}
+
+// Masked returns an Effect applying e only where the mask m is white,
+// blending its result with the input like mask.Blend. Applying it fails
+// with mask.ErrSizeMismatch when e changes the size of the image.
+//
+// Parameters:
+//   - e: The effect to apply
+//   - m: The grayscale mask, stretched to the size of the input when needed
+//
+// Returns:
+//   - Effect: An effect with the name, parameters and values of e
+//
+// Example:
+//
+//	blur, _ := effects.New("gaussian-blur", effects.Values{"level": 12})
+//	newImage, err := effects.Masked(blur, mask.RadialGradient(w, h, 0.5, 0.5, 0.6, 0.3)).Apply(img)
+func Masked(e Effect, m image.Image) Effect {
+	return &maskedEffect{Effect: e, mask: m}
+}
+
+type maskedEffect struct {
+	Effect
+	mask image.Image
+}
+
+func (e *maskedEffect) Apply(img image.Image) (image.Image, error) {
+	return mask.ApplyEffectContext(context.Background(), img, e.mask, func(_ context.Context, img image.Image, _ utils.ProgressFunc) (image.Image, error) {
+		return e.Effect.Apply(img)
+	}, nil)
+}
+
+func (e *maskedEffect) ApplyContext(ctx context.Context, img image.Image, progress utils.ProgressFunc) (image.Image, error) {
+	return mask.ApplyEffectContext(ctx, img, e.mask, e.Effect.ApplyContext, progress)
+}
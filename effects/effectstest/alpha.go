@@ -0,0 +1,139 @@
+package effectstest
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"maps"
+
+	"github.com/BrunoPoiano/imgeffects/effects"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// ErrAlphaReplaced is returned by CheckAlpha for effects whose output alpha
+// does not depend on the input alpha, e.g. generated noise or the gray
+// threshold images.
+var ErrAlphaReplaced = errors.New("effect replaces the alpha channel")
+
+// alphaOutliers sets the share of the pixels, one in alphaOutliers, whose
+// colour may differ in CheckAlpha. Storing colours at half opacity loses a bit
+// of precision, which effects taking discrete decisions (hue angles, Kuwahara
+// quadrants, thresholds) turn into larger differences on a few pixels.
+const alphaOutliers = 32
+
+// WithAlpha returns a copy of img with the same straight colours and the
+// given alpha on every pixel.
+//
+// Parameters:
+//   - img: The source image
+//   - alpha: The alpha of the copy (0-65535)
+//
+// Returns:
+//   - *image.NRGBA64
+func WithAlpha(img image.Image, alpha uint16) *image.NRGBA64 {
+	bounds := img.Bounds()
+	newImage := image.NewNRGBA64(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			c.A = alpha
+			newImage.SetNRGBA64(x, y, c)
+		}
+	}
+	return newImage
+}
+
+// CheckAlpha applies the effect to img and to a copy of img at half opacity,
+// and reports whether the effect follows the alpha policy of the repository:
+// the results hold valid premultiplied colours, the semi-transparent result
+// has the straight colours of the opaque one and its alpha is halved.
+// Effects with a "keep-alpha" parameter are checked with it set.
+//
+// Parameters:
+//   - def: The effect to check
+//   - values: The effect parameters, nil uses the defaults
+//   - img: The source image, should be opaque
+//   - tolerance: The largest accepted difference per straight 16-bit channel
+//
+// Returns:
+//   - error: A description of the first difference found, or ErrAlphaReplaced
+//     when the colours match but the output alpha ignores the input alpha
+func CheckAlpha(def effects.Definition, values effects.Values, img image.Image, tolerance uint32) error {
+	for _, p := range def.Params {
+		if p.Name == "keep-alpha" {
+			values = maps.Clone(values)
+			if values == nil {
+				values = effects.Values{}
+			}
+			values["keep-alpha"] = true
+		}
+	}
+
+	effect, err := def.New(values)
+	if err != nil {
+		return err
+	}
+
+	want, err := effect.Apply(img)
+	if err != nil {
+		return err
+	}
+	got, err := effect.Apply(WithAlpha(img, 0x8000))
+	if err != nil {
+		return err
+	}
+
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Size() != wb.Size() {
+		return fmt.Errorf("size %v, want %v", gb.Size(), wb.Size())
+	}
+
+	diff := func(a, b uint32) uint32 {
+		if a > b {
+			return a - b
+		}
+		return b - a
+	}
+
+	replaced := true
+	differ := 0
+	var first error
+	for y := 0; y < gb.Dy(); y++ {
+		for x := 0; x < gb.Dx(); x++ {
+			r1, g1, b1, a1 := got.At(gb.Min.X+x, gb.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := want.At(wb.Min.X+x, wb.Min.Y+y).RGBA()
+			if r1 > a1 || g1 > a1 || b1 > a1 {
+				return fmt.Errorf("pixel (%d, %d) is %v, which is not premultiplied", x, y,
+					color.RGBA64{uint16(r1), uint16(g1), uint16(b1), uint16(a1)})
+			}
+			if a1 != a2 {
+				replaced = false
+			}
+			if diff(a1, a2*0x8000/0xffff) > tolerance && a1 != a2 {
+				return fmt.Errorf("pixel (%d, %d) has alpha %d, want %d", x, y, a1, a2*0x8000/0xffff)
+			}
+			if a1 == 0 || a2 == 0 {
+				continue
+			}
+
+			sr1, sg1, sb1 := utils.Unpremultiply(r1, g1, b1, a1)
+			sr2, sg2, sb2 := utils.Unpremultiply(r2, g2, b2, a2)
+			if diff(sr1, sr2) > tolerance || diff(sg1, sg2) > tolerance || diff(sb1, sb2) > tolerance {
+				if first == nil {
+					first = fmt.Errorf("pixel (%d, %d) has the straight colour {%d %d %d} at half opacity, want {%d %d %d}",
+						x, y, sr1, sg1, sb1, sr2, sg2, sb2)
+				}
+				differ++
+			}
+		}
+	}
+
+	if differ > gb.Dx()*gb.Dy()/alphaOutliers {
+		return fmt.Errorf("%d of %d pixels differ, %w", differ, gb.Dx()*gb.Dy(), first)
+	}
+	if replaced {
+		return ErrAlphaReplaced
+	}
+	return nil
+}
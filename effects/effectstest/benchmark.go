@@ -0,0 +1,28 @@
+package effectstest
+
+import (
+	"image"
+	"testing"
+)
+
+// BenchmarkPixelAccess times apply on a 512x384 Gradient read through the
+// fast paths of internal/pixel ("fast") and on the same image with its type
+// hidden, so every pixel goes through image.Image.At ("at").
+//
+// Parameters:
+//   - b: The running benchmark
+//   - apply: The effect to time
+func BenchmarkPixelAccess(b *testing.B, apply func(image.Image) image.Image) {
+	img := Gradient(512, 384)
+	b.Run("fast", func(b *testing.B) {
+		for b.Loop() {
+			apply(img)
+		}
+	})
+	b.Run("at", func(b *testing.B) {
+		opaque := struct{ image.Image }{img}
+		for b.Loop() {
+			apply(opaque)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package effectstest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/BrunoPoiano/imgeffects/effects"
+)
+
+// Offset returns a copy of img whose bounds start at origin. The copy is a
+// SubImage of a larger canvas, so pixels outside of its bounds exist in memory
+// and must not leak into the result of an effect.
+//
+// Parameters:
+//   - img: The source image
+//   - origin: The top left corner of the returned image, may be negative
+//
+// Returns:
+//   - image.Image
+func Offset(img image.Image, origin image.Point) image.Image {
+	bounds := img.Bounds()
+	rect := image.Rectangle{Min: origin, Max: origin.Add(bounds.Size())}
+
+	canvas := image.NewNRGBA(rect.Inset(-8))
+	for y := canvas.Rect.Min.Y; y < canvas.Rect.Max.Y; y++ {
+		for x := canvas.Rect.Min.X; x < canvas.Rect.Max.X; x++ {
+			canvas.SetNRGBA(x, y, color.NRGBA{R: 255, B: 255, A: 255})
+		}
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			canvas.Set(x-bounds.Min.X+origin.X, y-bounds.Min.Y+origin.Y, img.At(x, y))
+		}
+	}
+
+	return canvas.SubImage(rect)
+}
+
+// CheckBounds applies the effect to img and to a copy of img whose bounds
+// start at origin, and reports whether both results hold the same pixels.
+// Effects producing an image of the input size must also keep the bounds of
+// their input.
+//
+// Parameters:
+//   - def: The effect to check
+//   - values: The effect parameters, nil uses the defaults
+//   - img: The source image, its bounds should start at (0, 0)
+//   - origin: The top left corner of the offset copy, may be negative
+//
+// Returns:
+//   - error: A description of the first difference found, wrapping ErrNondeterministic
+//     when runs on the same input differ too
+func CheckBounds(def effects.Definition, values effects.Values, img image.Image, origin image.Point) error {
+	effect, err := def.New(values)
+	if err != nil {
+		return err
+	}
+
+	want, err := effect.Apply(img)
+	if err != nil {
+		return err
+	}
+
+	offset := Offset(img, origin)
+	got, err := effect.Apply(offset)
+	if err != nil {
+		return err
+	}
+
+	if err := Compare(got, want, 0); err != nil {
+		if deterministic(effect, img, want) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrNondeterministic, err)
+	}
+	if want.Bounds() == img.Bounds() && got.Bounds() != offset.Bounds() {
+		return fmt.Errorf("bounds %v, want %v", got.Bounds(), offset.Bounds())
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Package effectstest provides checks shared by every registered effect.
+//
+// The tests of this package run them against every registered effect:
+//
+//	go test ./effects/effectstest
+//
+// and regenerate the golden images with
+//
+//	go test ./effects/effectstest -update
+package effectstest
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/BrunoPoiano/imgeffects/effects"
+)
+
+// ErrNondeterministic is returned by the checks when an effect produces
+// different results for the same input, so its results cannot be compared.
+var ErrNondeterministic = errors.New("effect is not deterministic")
+
+// deterministicRuns is the number of extra runs used to tell a wrong result
+// from a random one.
+const deterministicRuns = 5
+
+// deterministic reports whether applying effect to img again keeps producing want.
+func deterministic(effect effects.Effect, img, want image.Image) bool {
+	for i := 0; i < deterministicRuns; i++ {
+		again, err := effect.Apply(img)
+		if err != nil || Compare(again, want, 0) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare reports the first pixel where got and want differ by more than
+// tolerance on any premultiplied 16-bit channel. Pixels are compared relative
+// to the top left corner of each image, so the images may have different
+// origins but must have the same size.
+//
+// Parameters:
+//   - got: The image to check
+//   - want: The expected image
+//   - tolerance: The largest accepted difference per channel (0-65535)
+//
+// Returns:
+//   - error: nil when the images match
+func Compare(got, want image.Image, tolerance uint32) error {
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Size() != wb.Size() {
+		return fmt.Errorf("size %v, want %v", gb.Size(), wb.Size())
+	}
+
+	diff := func(a, b uint32) uint32 {
+		if a > b {
+			return a - b
+		}
+		return b - a
+	}
+
+	for y := 0; y < gb.Dy(); y++ {
+		for x := 0; x < gb.Dx(); x++ {
+			r1, g1, b1, a1 := got.At(gb.Min.X+x, gb.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := want.At(wb.Min.X+x, wb.Min.Y+y).RGBA()
+			if diff(r1, r2) > tolerance || diff(g1, g2) > tolerance || diff(b1, b2) > tolerance || diff(a1, a2) > tolerance {
+				return fmt.Errorf("pixel (%d, %d) is %v, want %v", x, y,
+					color.RGBA64{uint16(r1), uint16(g1), uint16(b1), uint16(a1)},
+					color.RGBA64{uint16(r2), uint16(g2), uint16(b2), uint16(a2)})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package effectstest_test
+
+import (
+	"errors"
+	"flag"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/effects"
+	"github.com/BrunoPoiano/imgeffects/effects/effectstest"
+)
+
+var (
+	update    = flag.Bool("update", false, "rewrite the golden images instead of comparing with them")
+	goldenDir = flag.String("golden", "testdata/golden", "directory holding the golden images, relative to this package")
+	tolerance = flag.Uint("tolerance", effectstest.DefaultTolerance, "largest accepted difference per 16-bit channel")
+)
+
+// alphaTolerance is the largest difference between straight colours accepted
+// by the alpha check. Colours stored at half opacity lose a bit of precision,
+// two for the effects working on 8-bit values.
+const alphaTolerance = 3 * 0x101
+
+// origins used for the offset copies, including a negative one.
+var origins = []image.Point{{37, 19}, {-23, -41}}
+
+// TestEffects checks every registered effect on copies of a fixture with
+// offset bounds, with the alpha check and against the golden images of each
+// of its parameter sets (see effectstest.Cases).
+func TestEffects(t *testing.T) {
+	fixture := effectstest.Gradient(48, 32)
+	var paths []string
+
+	for _, def := range effects.List() {
+		cases := effectstest.Cases(def)
+		for _, c := range cases {
+			for _, f := range effectstest.Fixtures() {
+				paths = append(paths, effectstest.GoldenPath(*goldenDir, c, f.Name))
+			}
+		}
+
+		t.Run(def.Name, func(t *testing.T) {
+			for _, origin := range origins {
+				if err := effectstest.CheckBounds(def, cases[0].Values, fixture, origin); err != nil {
+					t.Errorf("bounds %v: %v", origin, err)
+				}
+			}
+
+			err := effectstest.CheckAlpha(def, cases[0].Values, fixture, alphaTolerance)
+			switch {
+			case errors.Is(err, effectstest.ErrAlphaReplaced):
+				t.Logf("alpha: %v", err)
+			case err != nil:
+				t.Errorf("alpha: %v", err)
+			}
+
+			for _, c := range cases {
+				for _, f := range effectstest.Fixtures() {
+					path := effectstest.GoldenPath(*goldenDir, c, f.Name)
+					if err := effectstest.CheckGolden(c, f.Image, path, uint32(*tolerance), *update); err != nil {
+						t.Errorf("golden %q: %v", c.Name+"-"+f.Name, err)
+					}
+				}
+			}
+		})
+	}
+
+	// Stale goldens are only known when every effect was checked.
+	if flag.Lookup("test.run").Value.String() != "" {
+		return
+	}
+	stale, err := effectstest.StaleGoldens(*goldenDir, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range stale {
+		if *update {
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		t.Errorf("%s: stale golden image, run with -update to remove it", path)
+	}
+}
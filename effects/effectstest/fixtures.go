@@ -0,0 +1,135 @@
+package effectstest
+
+import (
+	"image"
+	"image/color"
+)
+
+// Gradient returns a deterministic opaque test image of the given size with
+// gradients, hard edges and fine detail, so that every effect has something
+// to work on.
+//
+// Parameters:
+//   - width: The width of the image in pixels
+//   - height: The height of the image in pixels
+//
+// Returns:
+//   - *image.NRGBA
+func Gradient(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{
+				R: uint8(x * 255 / max(width-1, 1)),
+				G: uint8(y * 255 / max(height-1, 1)),
+				B: uint8((x*x + y*y) * 7 % 256),
+				A: 255,
+			}
+			// a dark square with a bright stripe gives the edge based effects work
+			if x > width/4 && x < width/2 && y > height/4 && y < height/2 {
+				c.R, c.G, c.B = c.R/4, c.G/4, c.B/4
+			}
+			if (x+y)%11 == 0 {
+				c.R, c.G, c.B = 250, 240, 230
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// Checkerboard returns an opaque black and white checkerboard.
+//
+// Parameters:
+//   - width: The width of the image in pixels
+//   - height: The height of the image in pixels
+//   - cell: The size of a square in pixels
+//
+// Returns:
+//   - *image.NRGBA
+func Checkerboard(width, height, cell int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{A: 255}
+			if (x/cell+y/cell)%2 == 0 {
+				c.R, c.G, c.B = 255, 255, 255
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// colorBars are the colours of the bars drawn by ColorBars.
+var colorBars = []color.NRGBA{
+	{255, 255, 255, 255},
+	{255, 255, 0, 255},
+	{0, 255, 255, 255},
+	{0, 255, 0, 255},
+	{255, 0, 255, 255},
+	{255, 0, 0, 255},
+	{0, 0, 255, 255},
+	{0, 0, 0, 255},
+}
+
+// ColorBars returns opaque vertical bars of saturated colours.
+//
+// Parameters:
+//   - width: The width of the image in pixels
+//   - height: The height of the image in pixels
+//
+// Returns:
+//   - *image.NRGBA
+func ColorBars(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, colorBars[x*len(colorBars)/width])
+		}
+	}
+	return img
+}
+
+// TransparencyRamp returns a colour gradient whose alpha grows from fully
+// transparent on the left to opaque on the right.
+//
+// Parameters:
+//   - width: The width of the image in pixels
+//   - height: The height of the image in pixels
+//
+// Returns:
+//   - *image.NRGBA
+func TransparencyRamp(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(255 - y*255/max(height-1, 1)),
+				G: 128,
+				B: uint8(y * 255 / max(height-1, 1)),
+				A: uint8(x * 255 / max(width-1, 1)),
+			})
+		}
+	}
+	return img
+}
+
+// Fixture is a named test image.
+type Fixture struct {
+	Name  string
+	Image image.Image
+}
+
+// Fixtures returns the images used by the golden checks.
+//
+// Returns:
+//   - []Fixture
+func Fixtures() []Fixture {
+	return []Fixture{
+		{Name: "gradient", Image: Gradient(32, 24)},
+		{Name: "checkerboard", Image: Checkerboard(32, 24, 4)},
+		{Name: "color-bars", Image: ColorBars(32, 24)},
+		{Name: "transparency-ramp", Image: TransparencyRamp(32, 24)},
+	}
+}
@@ -0,0 +1,233 @@
+package effectstest
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BrunoPoiano/imgeffects/effects"
+)
+
+// DefaultTolerance is the largest difference per premultiplied 16-bit channel
+// accepted by the golden checks, one step of an 8-bit channel. It absorbs
+// floating point differences between platforms while catching visible changes.
+const DefaultTolerance = 0x101
+
+// ErrMissingGolden is returned by CheckGolden when the golden image does not
+// exist yet.
+var ErrMissingGolden = errors.New("missing golden image, run with -update to create it")
+
+// Case is a parameter set of an effect checked against golden images.
+//
+// Fields:
+//   - Effect: The registered effect name
+//   - Name: The name of the parameter set, unique for the effect
+//   - Values: The parameter values, nil uses the defaults
+type Case struct {
+	Effect string
+	Name   string
+	Values effects.Values
+}
+
+// Seed is the value given to the "seed" parameter of random effects by Cases,
+// so their results can be compared like the ones of any other effect.
+const Seed = 1
+
+// Cases returns the parameter sets checked for an effect: the defaults, every
+// option of its string parameters and the opposite of its bool defaults.
+// Effects with a "seed" parameter get Seed in every parameter set.
+//
+// Parameters:
+//   - def: The effect definition
+//
+// Returns:
+//   - []Case
+func Cases(def effects.Definition) []Case {
+	cases := []Case{{Effect: def.Name, Name: "default"}}
+
+	for _, p := range def.Params {
+		switch p.Type {
+		case effects.String:
+			for _, option := range p.Options {
+				if option == p.Default {
+					continue
+				}
+				cases = append(cases, Case{
+					Effect: def.Name,
+					Name:   p.Name + "-" + option,
+					Values: effects.Values{p.Name: option},
+				})
+			}
+		case effects.Bool:
+			value, _ := p.Default.(bool)
+			cases = append(cases, Case{
+				Effect: def.Name,
+				Name:   fmt.Sprintf("%s-%t", p.Name, !value),
+				Values: effects.Values{p.Name: !value},
+			})
+		}
+	}
+
+	for _, p := range def.Params {
+		if p.Name != "seed" {
+			continue
+		}
+		for i := range cases {
+			if cases[i].Values == nil {
+				cases[i].Values = effects.Values{}
+			}
+			cases[i].Values["seed"] = Seed
+		}
+	}
+
+	return cases
+}
+
+// GoldenPath returns the path of the golden image of a case and fixture.
+//
+// Parameters:
+//   - dir: The directory holding the golden images
+//   - c: The parameter set
+//   - fixture: The name of the fixture
+//
+// Returns:
+//   - string
+func GoldenPath(dir string, c Case, fixture string) string {
+	return filepath.Join(dir, c.Effect, c.Name+"-"+fixture+".png")
+}
+
+// CheckGolden applies the case to img and compares the result with the PNG
+// stored at path. With update set the result is written to path instead.
+// The result is encoded and decoded before comparing, so it goes through the
+// same PNG conversion as the golden image.
+//
+// Parameters:
+//   - c: The parameter set
+//   - img: The fixture
+//   - path: The golden image path
+//   - tolerance: The largest accepted difference per channel (0-65535)
+//   - update: Write the golden image instead of comparing
+//
+// Returns:
+//   - error: ErrMissingGolden when the golden image does not exist, or the first difference
+//     found. The error wraps ErrNondeterministic when the results also change between runs,
+//     which is a failure too: every random effect takes a seed.
+func CheckGolden(c Case, img image.Image, path string, tolerance uint32, update bool) error {
+	effect, err := effects.New(c.Effect, c.Values)
+	if err != nil {
+		return err
+	}
+
+	result, err := effect.Apply(img)
+	if err != nil {
+		return err
+	}
+
+	// The results of random effects are written as well, so a run that happens
+	// to look deterministic still finds a golden image to compare with.
+	if update {
+		if err := writePNG(path, result); err != nil {
+			return err
+		}
+		if !deterministic(effect, img, result) {
+			return fmt.Errorf("%w: the golden image would change on every update", ErrNondeterministic)
+		}
+		return nil
+	}
+
+	got, err := roundTrip(result)
+	if err != nil {
+		return err
+	}
+
+	want, err := readPNG(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		err = ErrMissingGolden
+	case err != nil:
+		return err
+	default:
+		err = Compare(got, want, tolerance)
+	}
+
+	// The runs are only repeated once the result does not match, to tell a
+	// regression from an effect that lost its determinism.
+	if err != nil && !deterministic(effect, img, result) {
+		return fmt.Errorf("%w: %v", ErrNondeterministic, err)
+	}
+	return err
+}
+
+// StaleGoldens returns the PNG files below dir that do not belong to any of
+// the given paths, e.g. the goldens of removed effects or parameter sets.
+//
+// Parameters:
+//   - dir: The directory holding the golden images
+//   - paths: The golden paths in use
+//
+// Returns:
+//   - []string: The stale files, sorted
+//   - error
+func StaleGoldens(dir string, paths []string) ([]string, error) {
+	used := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		used[filepath.Clean(path)] = true
+	}
+
+	var stale []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".png") && !used[filepath.Clean(path)] {
+			stale = append(stale, path)
+		}
+		return nil
+	})
+
+	sort.Strings(stale)
+	return stale, err
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return png.Decode(f)
+}
+
+// roundTrip encodes img as PNG and decodes it again.
+func roundTrip(img image.Image) (image.Image, error) {
+	var buf strings.Builder
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return png.Decode(strings.NewReader(buf.String()))
+}
@@ -0,0 +1,232 @@
+package effects
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// Step is a single effect of a Pipeline with its parameter values.
+//
+// Fields:
+//   - Effect: The registered effect name
+//   - Params: The parameter values, missing parameters take their default value
+type Step struct {
+	Effect string `json:"effect"`
+	Params Values `json:"params,omitempty"`
+}
+
+// Pipeline runs an ordered list of effects, feeding the output of each step
+// into the next one. A Pipeline can be saved to and loaded from a JSON recipe:
+//
+//	{
+//	  "name": "retro",
+//	  "steps": [
+//	    {"effect": "unsharp-masking", "params": {"variation": 0.6, "blur": 4}},
+//	    {"effect": "saturation", "params": {"change": 0.3}},
+//	    {"effect": "ordered-dither", "params": {"level": 4, "size": 4}}
+//	  ]
+//	}
+type Pipeline struct {
+	Name  string `json:"name,omitempty"`
+	Steps []Step `json:"steps"`
+}
+
+// StepProgressFunc receives progress updates of a running Pipeline.
+//
+// Parameters:
+//   - step: Index of the running step
+//   - steps: Total number of steps
+//   - done: Units of work completed by the running step
+//   - total: Total units of work of the running step
+type StepProgressFunc func(step, steps, done, total int)
+
+// StepError reports which step of a Pipeline failed.
+type StepError struct {
+	Index  int
+	Effect string
+	Err    error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("pipeline step %d (%s): %v", e.Index, e.Effect, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// NewPipeline returns a Pipeline running the given steps in order.
+func NewPipeline(steps ...Step) *Pipeline {
+	return &Pipeline{Steps: steps}
+}
+
+// Add appends a step to the pipeline and returns the pipeline to allow chaining.
+//
+// Parameters:
+//   - effect: The registered effect name
+//   - params: The parameter values of the step
+//
+// Returns:
+//   - *Pipeline
+func (p *Pipeline) Add(effect string, params Values) *Pipeline {
+	p.Steps = append(p.Steps, Step{Effect: effect, Params: params})
+	return p
+}
+
+// Effects validates every step and returns the configured effects in order,
+// so a recipe can be rejected before any image is processed.
+//
+// Returns:
+//   - []Effect
+//   - error: A *StepError for the first invalid step
+func (p *Pipeline) Effects() ([]Effect, error) {
+	effects := make([]Effect, len(p.Steps))
+
+	for i, step := range p.Steps {
+		effect, err := New(step.Effect, step.Params)
+		if err != nil {
+			return nil, &StepError{Index: i, Effect: step.Effect, Err: err}
+		}
+		effects[i] = effect
+	}
+
+	return effects, nil
+}
+
+// Run applies every step of the pipeline to img in order.
+//
+// Parameters:
+//   - img: The source image
+//
+// Returns:
+//   - image.Image: The output of the last step, or img when the pipeline is empty
+//   - error: A *StepError for the step that failed
+func (p *Pipeline) Run(img image.Image) (image.Image, error) {
+	effects, err := p.Effects()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, effect := range effects {
+		img, err = effect.Apply(img)
+		if err != nil {
+			return nil, &StepError{Index: i, Effect: effect.Name(), Err: err}
+		}
+	}
+
+	return img, nil
+}
+
+// RunContext works like Run but aborts as soon as ctx is cancelled and reports
+// the progress of every step.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The source image
+//   - progress: Optional callback receiving the progress of the running step, may be nil
+//
+// Returns:
+//   - image.Image: The output of the last step, or img when the pipeline is empty
+//   - error: A *StepError for the step that failed or was cancelled
+func (p *Pipeline) RunContext(ctx context.Context, img image.Image, progress StepProgressFunc) (image.Image, error) {
+	effects, err := p.Effects()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, effect := range effects {
+		var stepProgress utils.ProgressFunc
+		if progress != nil {
+			step := i
+			stepProgress = func(done, total int) { progress(step, len(effects), done, total) }
+		}
+
+		img, err = effect.ApplyContext(ctx, img, stepProgress)
+		if err != nil {
+			return nil, &StepError{Index: i, Effect: effect.Name(), Err: err}
+		}
+	}
+
+	return img, nil
+}
+
+// ReadRecipe decodes a JSON recipe into a Pipeline and validates its steps.
+//
+// Parameters:
+//   - r: The reader containing the JSON recipe
+//
+// Returns:
+//   - *Pipeline
+//   - error: When the recipe is malformed or one of its steps is invalid
+func ReadRecipe(r io.Reader) (*Pipeline, error) {
+	var p Pipeline
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("effects: decoding recipe: %w", err)
+	}
+
+	if _, err := p.Effects(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// LoadRecipe reads and validates the JSON recipe stored at path.
+//
+// Parameters:
+//   - path: The recipe file path
+//
+// Returns:
+//   - *Pipeline
+//   - error
+func LoadRecipe(path string) (*Pipeline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadRecipe(file)
+}
+
+// WriteRecipe encodes the pipeline as an indented JSON recipe.
+//
+// Parameters:
+//   - w: The writer receiving the recipe
+//
+// Returns:
+//   - error
+func (p *Pipeline) WriteRecipe(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+// SaveRecipe writes the pipeline as a JSON recipe to path.
+//
+// Parameters:
+//   - path: The recipe file path, created or truncated
+//
+// Returns:
+//   - error
+func (p *Pipeline) SaveRecipe(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := p.WriteRecipe(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
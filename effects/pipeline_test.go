@@ -0,0 +1,248 @@
+package effects_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/effects"
+	"github.com/BrunoPoiano/imgeffects/flip"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+func testImage() image.Image {
+	img := image.NewNRGBA(image.Rect(-3, 2, 13, 12))
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 16), uint8(y * 20), uint8(x * y), uint8(255 - x*8)})
+		}
+	}
+	return img
+}
+
+func retro() *effects.Pipeline {
+	p := effects.NewPipeline().
+		Add("gaussian-blur", effects.Values{"level": 3}).
+		Add("rotate-90", nil).
+		Add("dither", effects.Values{"algorithm": "atkinson", "level": 4})
+	p.Name = "retro"
+	return p
+}
+
+// values returns the normalized values of every step of p.
+func values(t *testing.T, p *effects.Pipeline) []effects.Values {
+	t.Helper()
+	list, err := p.Effects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make([]effects.Values, len(list))
+	for i, e := range list {
+		values[i] = e.Values()
+	}
+	return values
+}
+
+func equalImages(a, b image.Image) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			if a.At(x, y) != b.At(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestRecipeRoundTrip(t *testing.T) {
+	p := retro()
+
+	var recipe bytes.Buffer
+	if err := p.WriteRecipe(&recipe); err != nil {
+		t.Fatal(err)
+	}
+	read, err := effects.ReadRecipe(bytes.NewReader(recipe.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "retro.json")
+	if err := read.SaveRecipe(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := effects.LoadRecipe(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, got := range []*effects.Pipeline{read, loaded} {
+		if got.Name != p.Name || len(got.Steps) != len(p.Steps) {
+			t.Fatalf("got %q with %d steps, want %q with %d", got.Name, len(got.Steps), p.Name, len(p.Steps))
+		}
+		for i, step := range got.Steps {
+			if step.Effect != p.Steps[i].Effect {
+				t.Errorf("step %d: got %s, want %s", i, step.Effect, p.Steps[i].Effect)
+			}
+		}
+		if want := values(t, p); !reflect.DeepEqual(values(t, got), want) {
+			t.Errorf("got values %v, want %v", values(t, got), want)
+		}
+	}
+
+	img := testImage()
+	want, err := p.Run(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loaded.Run(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalImages(got, want) {
+		t.Error("the loaded recipe gives another image")
+	}
+}
+
+func TestReadRecipeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, recipe string
+		step         int
+		effect       string
+		contains     string
+	}{
+		{"unknown effect", `{"steps": [{"effect": "invert"}, {"effect": "no-such-effect"}]}`, 1, "no-such-effect", "no-such-effect"},
+		{"unknown parameter", `{"steps": [{"effect": "gaussian-blur", "params": {"radius": 3}}]}`, 0, "gaussian-blur", `unknown parameter "radius"`},
+		{"wrong type", `{"steps": [{"effect": "gaussian-blur", "params": {"level": "high"}}]}`, 0, "gaussian-blur", "expected int"},
+		{"fractional int", `{"steps": [{"effect": "gaussian-blur", "params": {"level": 2.5}}]}`, 0, "gaussian-blur", "expected int"},
+		{"unknown option", `{"steps": [{"effect": "invert"}, {"effect": "invert"}, {"effect": "dither", "params": {"algorithm": "random"}}]}`, 2, "dither", "random"},
+		{"out of range", `{"steps": [{"effect": "gaussian-blur", "params": {"level": 1000}}]}`, 0, "gaussian-blur", "1000"},
+		{"unknown field", `{"steps": [], "author": "me"}`, -1, "", "author"},
+		{"malformed", `{"steps": [`, -1, "", "decoding recipe"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := effects.ReadRecipe(strings.NewReader(tc.recipe))
+			if err == nil {
+				t.Fatalf("got %+v, want an error", p)
+			}
+			if !strings.Contains(err.Error(), tc.contains) {
+				t.Errorf("error %q does not mention %q", err, tc.contains)
+			}
+
+			var stepErr *effects.StepError
+			if tc.step < 0 {
+				if errors.As(err, &stepErr) {
+					t.Errorf("got a StepError for a malformed recipe: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &stepErr) || stepErr.Index != tc.step || stepErr.Effect != tc.effect {
+				t.Fatalf("got %v, want a StepError for step %d (%s)", err, tc.step, tc.effect)
+			}
+		})
+	}
+
+	// Values out of range and unknown options are validation errors.
+	for _, recipe := range []string{
+		`{"steps": [{"effect": "gaussian-blur", "params": {"level": -1}}]}`,
+		`{"steps": [{"effect": "dither", "params": {"algorithm": "random"}}]}`,
+	} {
+		var validation *utils.ValidationError
+		if _, err := effects.ReadRecipe(strings.NewReader(recipe)); !errors.As(err, &validation) {
+			t.Errorf("%s: got %v, want a *utils.ValidationError", recipe, err)
+		}
+	}
+}
+
+// TestPipelineOrder checks that the steps run in order, each one on the
+// output of the previous one.
+func TestPipelineOrder(t *testing.T) {
+	p := retro()
+	img := testImage()
+
+	want := img
+	for _, e := range must(p.Effects()) {
+		want = must(e.Apply(want))
+	}
+	got, err := p.Run(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalImages(got, want) {
+		t.Error("Run differs from applying the steps one after the other")
+	}
+
+	var steps []int
+	got, err = p.RunContext(context.Background(), img, func(step, count, done, total int) {
+		if count != len(p.Steps) || done > total {
+			t.Errorf("progress %d/%d of step %d/%d", done, total, step, count)
+		}
+		if len(steps) == 0 || steps[len(steps)-1] != step {
+			steps = append(steps, step)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(steps, []int{0, 1, 2}) {
+		t.Errorf("progress reported steps %v, want [0 1 2]", steps)
+	}
+	if got.Bounds().Dx() != img.Bounds().Dy() {
+		t.Errorf("RunContext gives bounds %v, the rotation did not run", got.Bounds())
+	}
+
+	// Swapping two steps changes the result.
+	swapped := effects.NewPipeline(p.Steps[1], p.Steps[0], p.Steps[2])
+	if equalImages(must(swapped.Run(img)), want) {
+		t.Error("swapping the steps gives the same image")
+	}
+
+	if got, err := effects.NewPipeline().Run(img); err != nil || got != img {
+		t.Errorf("an empty pipeline gives %v, %v, want the input", got, err)
+	}
+}
+
+func TestStepError(t *testing.T) {
+	p := effects.NewPipeline().
+		Add("invert", nil).
+		Add("affine", effects.Values{"scale-x": 0})
+	img := testImage()
+
+	_, runErr := p.Run(img)
+	_, contextErr := p.RunContext(context.Background(), img, nil)
+	for _, err := range []error{runErr, contextErr} {
+		var stepErr *effects.StepError
+		if !errors.As(err, &stepErr) || stepErr.Index != 1 || stepErr.Effect != "affine" {
+			t.Fatalf("got %v, want a StepError for step 1 (affine)", err)
+		}
+		if !errors.Is(err, flip.ErrSingularMatrix) {
+			t.Errorf("%v does not wrap flip.ErrSingularMatrix", err)
+		}
+		if want := "pipeline step 1 (affine): " + flip.ErrSingularMatrix.Error(); err.Error() != want {
+			t.Errorf("got message %q, want %q", err, want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := retro().RunContext(ctx, img, nil)
+	var stepErr *effects.StepError
+	if !errors.As(err, &stepErr) || stepErr.Index != 0 || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want a StepError for step 0 wrapping context.Canceled", err)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,84 @@
+package effects
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Definition{}
+)
+
+// Register makes an effect available by name.
+// It panics if the name is empty, the Run function is nil or an effect
+// with the same name is already registered.
+//
+// Parameters:
+//   - def: The effect definition to register
+func Register(def Definition) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if def.Name == "" {
+		panic("effects: Register with empty name")
+	}
+	if def.Run == nil {
+		panic("effects: Register " + def.Name + " with nil Run")
+	}
+	if _, dup := registry[def.Name]; dup {
+		panic("effects: Register called twice for " + def.Name)
+	}
+	registry[def.Name] = def
+}
+
+// Lookup returns the definition registered under name.
+//
+// Parameters:
+//   - name: The registered effect name
+//
+// Returns:
+//   - Definition: The effect definition
+//   - bool: false when no effect is registered under name
+func Lookup(name string) (Definition, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	def, ok := registry[name]
+	return def, ok
+}
+
+// List returns every registered effect definition sorted by name.
+//
+// Returns:
+//   - []Definition
+func List() []Definition {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+
+	return defs
+}
+
+// New returns the effect registered under name configured with values.
+//
+// Parameters:
+//   - name: The registered effect name
+//   - values: The parameter values, missing parameters take their default value
+//
+// Returns:
+//   - Effect: The configured effect
+//   - error: When the effect does not exist or the values are invalid
+func New(name string, values Values) (Effect, error) {
+	def, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("effects: unknown effect %q", name)
+	}
+	return def.New(values)
+}
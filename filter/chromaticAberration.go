@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -23,6 +24,7 @@ import (
 func ChromaticAberration(img image.Image, x_offset, y_offset int) image.Image {
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
 	x_offset = utils.ClampGeneric(x_offset, 1, 20)
 	y_offset = utils.ClampGeneric(y_offset, 1, 20)
@@ -30,14 +32,17 @@ func ChromaticAberration(img image.Image, x_offset, y_offset int) image.Image {
 	blue_y_offset := y_offset * -1
 	blue_x_offset := x_offset * -1
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-			r, _, _, a := img.At(x+x_offset, y+y_offset).RGBA()
-			_, g, _, _ := img.At(x, y).RGBA()
-			_, _, b, _ := img.At(x+blue_x_offset, y+blue_y_offset).RGBA()
+			r, _, _, a := reader.RGBA(x+x_offset, y+y_offset)
+			r, _, _ = utils.Unpremultiply(r, 0, 0, a)
+			_, g, _, ga := reader.RGBA(x, y)
+			_, g, _ = utils.Unpremultiply(0, g, 0, ga)
+			_, _, b, ba := reader.RGBA(x+blue_x_offset, y+blue_y_offset)
+			_, _, b = utils.Unpremultiply(0, 0, b, ba)
 
-			newImage.Set(x, y, color.NRGBA64{
+			newImage.SetNRGBA64(x, y, color.NRGBA64{
 				uint16(r),
 				uint16(g),
 				uint16(b),
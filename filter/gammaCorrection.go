@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"math"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -27,7 +28,8 @@ import (
 //   - image.Image
 func GammaCorrection(img image.Image, gamma float64) image.Image {
 	bounds := img.Bounds()
-	newImage := image.NewRGBA64(bounds)
+	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
 	gamma = float64(utils.ClampGeneric(int(gamma), -10, 10))
 	if gamma == 0 {
@@ -38,9 +40,10 @@ func GammaCorrection(img image.Image, gamma float64) image.Image {
 		effectiveGamma = 1.0 / -gamma
 	}
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 
 			stretch := func(value uint32) uint16 {
 				normalized := float64(value) / 65535.0
@@ -48,7 +51,7 @@ func GammaCorrection(img image.Image, gamma float64) image.Image {
 				return uint16(corrected * 65535)
 			}
 
-			newImage.Set(x, y, color.RGBA64{
+			newImage.SetNRGBA64(x, y, color.NRGBA64{
 				stretch(r),
 				stretch(g),
 				stretch(b),
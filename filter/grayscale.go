@@ -3,6 +3,9 @@ package filter
 import (
 	"image"
 	"image/color"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
 // GrayScale16 converts the given image to grayscale using 16-bit color depth.
@@ -17,10 +20,15 @@ import (
 func GrayScale16(img image.Image) image.Image {
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			newImage.Set(x, y, color.Gray16Model.Convert(img.At(x, y)))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
+			// Same weights as color.Gray16Model.
+			gray := uint16((19595*r + 38470*g + 7471*b + 1<<15) >> 16)
+			newImage.SetNRGBA64(x, y, color.NRGBA64{gray, gray, gray, uint16(a)})
 		}
 	}
 	return newImage
@@ -38,10 +46,15 @@ func GrayScale16(img image.Image) image.Image {
 func GrayScale(img image.Image) image.Image {
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			newImage.Set(x, y, color.GrayModel.Convert(img.At(x, y)))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
+			// Same weights as color.GrayModel.
+			gray := uint16((19595*r+38470*g+7471*b+1<<15)>>24) * 0x101
+			newImage.SetNRGBA64(x, y, color.NRGBA64{gray, gray, gray, uint16(a)})
 		}
 	}
 	return newImage
@@ -2,7 +2,9 @@ package filter
 
 import (
 	"image"
-	"image/color"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
 // Invert creates a negative image by inverting all color channels of the input image.
@@ -19,17 +21,18 @@ func Invert(img image.Image) image.Image {
 
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
+	writer := pixel.NewWriter(newImage)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-			r, g, b, a := img.At(x, y).RGBA()
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 
-			rr := uint16(65535 - r)
-			gg := uint16(65535 - g)
-			bb := uint16(65535 - b)
+			rr, gg, bb := utils.Premultiply(65535-r, 65535-g, 65535-b, a)
 
-			newImage.Set(x, y, color.RGBA64{rr, gg, bb, uint16(a)})
+			writer.SetRGBA(x, y, rr, gg, bb, a)
 		}
 	}
 
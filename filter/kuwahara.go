@@ -1,11 +1,13 @@
 package filter
 
 import (
+	"context"
 	"image"
 	"image/color"
 	"math"
 	"sync"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -38,29 +40,34 @@ func stdDev(values []float64) float64 {
 	return math.Sqrt(variance / n)
 }
 
-func averageRGB(img image.Image, x1, y1, x2, y2 int) color.Color {
-	var sumR, sumG, sumB float64
+// averageRGB returns the average premultiplied colour of the pixels of
+// [x1, x2) x [y1, y2) inside bounds. Pixels outside the image are left out,
+// so the edges keep their colour instead of fading to transparent black.
+func averageRGB(reader pixel.Reader, bounds image.Rectangle, x1, y1, x2, y2 int) color.Color {
+	var sumR, sumG, sumB, sumA float64
 	count := 0
 
-	for y := y1; y < y2; y++ {
-		for x := x1; x < x2; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
+	area := image.Rect(x1, y1, x2, y2).Intersect(bounds)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
 			sumR += float64(r)
 			sumG += float64(g)
 			sumB += float64(b)
+			sumA += float64(a)
 			count++
 		}
 	}
 
 	if count == 0 {
-		return color.Black
+		return color.Transparent
 	}
 
 	return color.RGBA{
 		R: uint8(sumR / float64(count) / 256),
 		G: uint8(sumG / float64(count) / 256),
 		B: uint8(sumB / float64(count) / 256),
-		A: 255,
+		A: uint8(sumA / float64(count) / 256),
 	}
 }
 
@@ -79,9 +86,27 @@ func averageRGB(img image.Image, x1, y1, x2, y2 int) color.Color {
 //
 // The implementation uses parallel processing for improved performance.
 func KuwaharaFilter(img image.Image, size int) image.Image {
+	newImage, _ := KuwaharaFilterContext(context.Background(), img, size, nil)
+	return newImage
+}
+
+// KuwaharaFilterContext works like KuwaharaFilter but aborts as soon as ctx is
+// cancelled and reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be filtered
+//   - size: Filter window size (1-30)
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The filtered image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func KuwaharaFilterContext(ctx context.Context, img image.Image, size int, progress utils.ProgressFunc) (image.Image, error) {
 	bounds := img.Bounds()
 	width, height := bounds.Max.X, bounds.Max.Y
 	size = utils.ClampGeneric(size, 1, 30)
+	reader := pixel.NewReader(img)
 
 	halfWin := size / 2
 	quadSize := int(math.Ceil(float64(size) / 2.0))
@@ -92,7 +117,11 @@ func KuwaharaFilter(img image.Image, size int) image.Image {
 
 	kuwaFunc := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
 		defer wg.Done()
+		var values []float64
 		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
 			for x := bounds.Min.X; x < width; x++ {
 				//quadrants
 				tlX, tlY := x-halfWin, y-halfWin
@@ -102,10 +131,10 @@ func KuwaharaFilter(img image.Image, size int) image.Image {
 				q4X, q4Y := tlX+size, tlY+size
 
 				//clamp values
-				q1X, q1Y = clamp(q1X, 0, width), clamp(q1Y, 0, height)
-				q2X, q2Y = clamp(q2X, 0, width), clamp(q2Y, 0, height)
-				q3X, q3Y = clamp(q3X, 0, width), clamp(q3Y, 0, height)
-				q4X, q4Y = clamp(q4X, 0, width), clamp(q4Y, 0, height)
+				q1X, q1Y = clamp(q1X, bounds.Min.X, width), clamp(q1Y, bounds.Min.Y, height)
+				q2X, q2Y = clamp(q2X, bounds.Min.X, width), clamp(q2Y, bounds.Min.Y, height)
+				q3X, q3Y = clamp(q3X, bounds.Min.X, width), clamp(q3Y, bounds.Min.Y, height)
+				q4X, q4Y = clamp(q4X, bounds.Min.X, width), clamp(q4Y, bounds.Min.Y, height)
 
 				//extracting brightness
 				quadrands := []struct {
@@ -122,13 +151,21 @@ func KuwaharaFilter(img image.Image, size int) image.Image {
 				bestQuad := quadrands[0]
 
 				for _, quad := range quadrands {
-					var values []float64
+					values = values[:0]
 					for yy := quad.y1; yy < quad.y2; yy++ {
 						for xx := quad.x1; xx < quad.x2; xx++ {
-							values = append(values, RGBToHSV(img.At(xx, yy)))
+							r, g, b, a := reader.RGBA(xx, yy)
+							r, g, b = utils.Unpremultiply(r, g, b, a)
+							values = append(values, float64(max(r, g, b))/65535.0)
 						}
 					}
 
+					// Quadrants clamped away at the right and bottom edges hold
+					// no pixel and would always win with a deviation of 0.
+					if len(values) == 0 {
+						continue
+					}
+
 					stdDevVal := stdDev(values)
 					if stdDevVal < minStdDev {
 						minStdDev = stdDevVal
@@ -137,10 +174,10 @@ func KuwaharaFilter(img image.Image, size int) image.Image {
 				}
 
 				// Assign the average color of the best quadrant
-				newImage.Set(x, y, averageRGB(img, bestQuad.x1, bestQuad.y1, bestQuad.x2, bestQuad.y2))
+				newImage.Set(x, y, averageRGB(reader, bounds, bestQuad.x1, bestQuad.y1, bestQuad.x2, bestQuad.y2))
 			}
 		}
 
 	}
-	return utils.ParallelExecution(utils.ParallelExecutionStruct{Image: img, Function: kuwaFunc})
+	return utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{Image: img, Function: kuwaFunc, Progress: progress})
 }
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -23,25 +24,27 @@ import (
 func SolarizeEffect(img image.Image, level int) image.Image {
 	level = utils.ClampGeneric(level, 1, 100)
 	bounds := img.Bounds()
-	newImage := image.NewRGBA64(bounds)
+	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
 	treshold_level := (65535.0 * level) / 100
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-			r, g, b, a := img.At(x, y).RGBA()
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			pixel := utils.Luminance16bit(r, g, b)
 
 			if pixel > float64(treshold_level) {
-				newImage.Set(x, y, color.RGBA64{
+				newImage.SetNRGBA64(x, y, color.NRGBA64{
 					uint16(65535.0 - r),
 					uint16(65535.0 - g),
 					uint16(65535.0 - b),
 					uint16(a),
 				})
 			} else {
-				newImage.Set(x, y, color.RGBA64{
+				newImage.SetNRGBA64(x, y, color.NRGBA64{
 					uint16(r),
 					uint16(g),
 					uint16(b),
@@ -1,10 +1,11 @@
 package filter
 
 import (
+	"context"
 	"image"
 	"image/color"
 	"math"
-	"math/rand"
+	"math/rand/v2"
 	"sync"
 
 	"github.com/BrunoPoiano/imgeffects/utils"
@@ -21,13 +22,13 @@ func distance(p1, p2 Point) float64 {
 	return math.Sqrt(dx*dx + dy*dy)
 }
 
-func calcPoints(img image.Image, seed int) []Point {
+func calcPoints(img image.Image, seed int, random *rand.Rand) []Point {
 	bounds := img.Bounds()
 
 	var seeds []Point
 	for i := 0; i < seed; i++ {
-		x := rand.Intn(bounds.Dx())
-		y := rand.Intn(bounds.Dy())
+		x := bounds.Min.X + random.IntN(bounds.Dx())
+		y := bounds.Min.Y + random.IntN(bounds.Dy())
 
 		seeds = append(seeds, Point{X: x, Y: y, Color: img.At(x, y)})
 	}
@@ -55,14 +56,67 @@ func calcPoints(img image.Image, seed int) []Point {
 //
 // The function uses parallel processing for improved performance on multi-core systems.
 func VoronoiPixelation(img image.Image, seed int) image.Image {
+	newImage, _ := VoronoiPixelationContext(context.Background(), img, seed, nil)
+	return newImage
+}
+
+// VoronoiPixelationContext works like VoronoiPixelation but aborts as soon as ctx is
+// cancelled and reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be processed
+//   - seed: Number of seed points to generate (1-100000)
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The processed image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func VoronoiPixelationContext(ctx context.Context, img image.Image, seed int, progress utils.ProgressFunc) (image.Image, error) {
+	return VoronoiPixelationWithSourceContext(ctx, img, seed, nil, progress)
+}
+
+// VoronoiPixelationWithSource works like VoronoiPixelation but places the seed
+// points with src, so the same source state always produces the same cells.
+//
+// Parameters:
+//   - img: The input image to be processed
+//   - seed: Number of seed points to generate (1-100000)
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image: The processed image with the Voronoi pixelation effect applied
+func VoronoiPixelationWithSource(img image.Image, seed int, src rand.Source) image.Image {
+	newImage, _ := VoronoiPixelationWithSourceContext(context.Background(), img, seed, src, nil)
+	return newImage
+}
+
+// VoronoiPixelationWithSourceContext combines VoronoiPixelationWithSource and
+// VoronoiPixelationContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be processed
+//   - seed: Number of seed points to generate (1-100000)
+//   - src: The random source, nil uses a randomly seeded one
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The processed image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func VoronoiPixelationWithSourceContext(ctx context.Context, img image.Image, seed int, src rand.Source, progress utils.ProgressFunc) (image.Image, error) {
 	bounds := img.Bounds()
-	seeds := calcPoints(img, seed)
 	seed = utils.ClampGeneric(seed, 1, 100000)
+	// The points are placed before the rows are split between the goroutines.
+	seeds := calcPoints(img, seed, utils.NewRand(src))
 
 	voronoiPixelationFunction := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
 		defer wg.Done()
 		for y := start; y < end; y++ {
-			for x := 0; x < bounds.Max.X; x++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
 				minDistance := math.MaxFloat64
 				var nearestColor color.Color
@@ -80,5 +134,5 @@ func VoronoiPixelation(img image.Image, seed int) image.Image {
 		}
 	}
 
-	return utils.ParallelExecution(utils.ParallelExecutionStruct{Image: img, Function: voronoiPixelationFunction})
+	return utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{Image: img, Function: voronoiPixelationFunction, Progress: progress})
 }
@@ -0,0 +1,349 @@
+package flip
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/resize"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// ErrSingularMatrix is returned by AffineStrict for a matrix without inverse,
+// which collapses the image to a line or a point.
+var ErrSingularMatrix = errors.New("affine matrix is not invertible")
+
+// Matrix is a 2D affine transform mapping the point (x, y) to
+// (m[0]*x + m[1]*y + m[2], m[3]*x + m[4]*y + m[5]). The y axis points down,
+// like the image coordinates.
+type Matrix [6]float64
+
+// Identity returns the transform leaving every point in place.
+func Identity() Matrix {
+	return Matrix{1, 0, 0, 0, 1, 0}
+}
+
+// Translate returns the transform moving every point by (tx, ty).
+func Translate(tx, ty float64) Matrix {
+	return Matrix{1, 0, tx, 0, 1, ty}
+}
+
+// Scale returns the transform scaling the x axis by sx and the y axis by sy.
+func Scale(sx, sy float64) Matrix {
+	return Matrix{sx, 0, 0, 0, sy, 0}
+}
+
+// Shear returns the transform shifting x by kx times y and y by ky times x.
+func Shear(kx, ky float64) Matrix {
+	return Matrix{1, kx, 0, ky, 1, 0}
+}
+
+// Rotation returns the transform rotating around the origin by angle degrees,
+// clockwise on screen.
+func Rotation(angle float64) Matrix {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	return Matrix{cos, -sin, 0, sin, cos, 0}
+}
+
+// Then returns the transform applying m first and n second.
+//
+// Parameters:
+//   - n: The transform applied after m
+//
+// Returns:
+//   - Matrix
+func (m Matrix) Then(n Matrix) Matrix {
+	return Matrix{
+		n[0]*m[0] + n[1]*m[3], n[0]*m[1] + n[1]*m[4], n[0]*m[2] + n[1]*m[5] + n[2],
+		n[3]*m[0] + n[4]*m[3], n[3]*m[1] + n[4]*m[4], n[3]*m[2] + n[4]*m[5] + n[5],
+	}
+}
+
+// Apply returns the point (x, y) transformed by m.
+func (m Matrix) Apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[1]*y + m[2], m[3]*x + m[4]*y + m[5]
+}
+
+// Invert returns the transform undoing m.
+//
+// Returns:
+//   - Matrix
+//   - bool: false when m is not invertible
+func (m Matrix) Invert() (Matrix, bool) {
+	det := m[0]*m[4] - m[1]*m[3]
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Matrix{}, false
+	}
+	return Matrix{
+		m[4] / det, -m[1] / det, (m[1]*m[5] - m[4]*m[2]) / det,
+		-m[3] / det, m[0] / det, (m[3]*m[2] - m[0]*m[5]) / det,
+	}, true
+}
+
+// TransformOptions configures Affine and Rotate.
+//
+// Fields:
+//   - Filter: How the source is sampled, empty or unknown means resize.Bilinear and
+//     resize.Box picks the nearest pixel
+//   - Background: The colour of the areas outside the source image, nil is transparent
+type TransformOptions struct {
+	Filter     resize.Filter
+	Background color.Color
+}
+
+// Affine transforms an image with the matrix m. Each pixel of the result is
+// sampled at the position m maps onto it, so the whole transform costs one
+// pass whatever the matrix. The coordinates of m are relative to the top
+// left corner of img, and the result starts at the origin of img.
+// Large reductions alias, resize the image first to avoid it.
+//
+// Parameters:
+//   - img: The input image to be transformed
+//   - m: The transform from the source to the result coordinates
+//   - width: The width of the result (in pixels)
+//   - height: The height of the result (in pixels)
+//   - opts: The filter and the background colour
+//
+// Returns:
+//   - image.Image: A new RGBA64 image, transparent or filled with the background
+//     colour when m is not invertible
+//
+// Example:
+//
+//	m := flip.Shear(0.3, 0).Then(flip.Translate(-20, 0))
+//	sheared := flip.Affine(img, m, 200, 100, flip.TransformOptions{Background: color.White})
+func Affine(img image.Image, m Matrix, width, height int, opts TransformOptions) image.Image {
+	newImage, _ := AffineContext(context.Background(), img, m, width, height, opts, nil)
+	return newImage
+}
+
+// AffineContext works like Affine but aborts as soon as ctx is cancelled and
+// reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be transformed
+//   - m: The transform from the source to the result coordinates
+//   - width: The width of the result (in pixels)
+//   - height: The height of the result (in pixels)
+//   - opts: The filter and the background colour
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The transformed image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func AffineContext(ctx context.Context, img image.Image, m Matrix, width, height int, opts TransformOptions, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	newImage := image.NewRGBA64(image.Rectangle{bounds.Min, bounds.Min.Add(image.Point{max(width, 0), max(height, 0)})})
+
+	filter := opts.Filter
+	if filter == "" {
+		filter = resize.Bilinear
+	}
+	sampler := resize.NewSampler(img, filter)
+
+	var br, bg, bb, ba uint32
+	if opts.Background != nil {
+		br, bg, bb, ba = opts.Background.RGBA()
+	}
+
+	inverse, ok := m.Invert()
+	if !ok || newImage.Rect.Empty() {
+		if opts.Background != nil {
+			draw.Draw(newImage, newImage.Rect, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		}
+		return newImage, nil
+	}
+
+	affineFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for x := 0; x < width; x++ {
+				c := sampler.At(inverse.Apply(float64(x)+0.5, float64(y)+0.5))
+
+				// The area outside the source is transparent, composite it
+				// over the background.
+				if ba != 0 {
+					rest := 0xffff - uint32(c.A)
+					c = color.RGBA64{
+						uint16(uint32(c.R) + br*rest/0xffff),
+						uint16(uint32(c.G) + bg*rest/0xffff),
+						uint16(uint32(c.B) + bb*rest/0xffff),
+						uint16(uint32(c.A) + ba*rest/0xffff),
+					}
+				}
+				newImage.SetRGBA64(bounds.Min.X+x, bounds.Min.Y+y, c)
+			}
+		}
+	}
+
+	_, err := utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{
+		Image:    img,
+		Function: affineFunc,
+		EndSize:  height,
+		Progress: progress,
+		NoOutput: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newImage, nil
+}
+
+// AffineStrict works like Affine but returns an error for a matrix without
+// inverse, an unknown filter or an empty size.
+//
+// Parameters:
+//   - img: The input image to be transformed
+//   - m: The transform from the source to the result coordinates
+//   - width: The width of the result (1-65535)
+//   - height: The height of the result (1-65535)
+//   - opts: The filter and the background colour
+//
+// Returns:
+//   - image.Image
+//   - error: ErrSingularMatrix or a *utils.ValidationError describing the first invalid parameter
+func AffineStrict(img image.Image, m Matrix, width, height int, opts TransformOptions) (image.Image, error) {
+	return AffineStrictContext(context.Background(), img, m, width, height, opts, nil)
+}
+
+// AffineStrictContext works like AffineStrict but aborts as soon as ctx is
+// cancelled and reports progress like AffineContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be transformed
+//   - m: The transform from the source to the result coordinates
+//   - width: The width of the result (1-65535)
+//   - height: The height of the result (1-65535)
+//   - opts: The filter and the background colour
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The transformed image, nil when cancelled or invalid
+//   - error: ErrSingularMatrix, a *utils.ValidationError, or ctx.Err() when the context was cancelled
+func AffineStrictContext(ctx context.Context, img image.Image, m Matrix, width, height int, opts TransformOptions, progress utils.ProgressFunc) (image.Image, error) {
+	if _, ok := m.Invert(); !ok {
+		return nil, ErrSingularMatrix
+	}
+	if err := utils.ValidateRange("width", width, 1, 65535); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("height", height, 1, 65535); err != nil {
+		return nil, err
+	}
+	if opts.Filter != "" {
+		if err := utils.ValidateOption("filter", opts.Filter, resize.Filters()); err != nil {
+			return nil, err
+		}
+	}
+	return AffineContext(ctx, img, m, width, height, opts, progress)
+}
+
+// Rotate rotates the image clockwise by angle degrees around its centre.
+// Multiples of 90 degrees use the exact Rotate90, Rotate180 and Rotate270
+// when the result keeps every pixel.
+//
+// Parameters:
+//   - img: The input image to be rotated
+//   - angle: The rotation in degrees, negative values rotate counter-clockwise
+//   - expand: Grow the result to hold the whole rotated image, otherwise it keeps
+//     the size of img and the corners are cut
+//   - opts: The filter and the colour of the uncovered corners
+//
+// Returns:
+//   - image.Image
+func Rotate(img image.Image, angle float64, expand bool, opts TransformOptions) image.Image {
+	newImage, _ := RotateContext(context.Background(), img, angle, expand, opts, nil)
+	return newImage
+}
+
+// RotateContext works like Rotate but aborts as soon as ctx is cancelled and
+// reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be rotated
+//   - angle: The rotation in degrees
+//   - expand: Grow the result to hold the whole rotated image
+//   - opts: The filter and the colour of the uncovered corners
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The rotated image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func RotateContext(ctx context.Context, img image.Image, angle float64, expand bool, opts TransformOptions, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+
+	turns := math.Mod(math.Mod(angle, 360)+360, 360)
+	square := bounds.Dx() == bounds.Dy()
+	switch {
+	case turns == 0:
+		return remap(img, bounds.Size(), func(x, y int) (int, int) { return x, y }), nil
+	case turns == 180:
+		return Rotate180(img), nil
+	case turns == 90 && (expand || square):
+		return Rotate90(img), nil
+	case turns == 270 && (expand || square):
+		return Rotate270(img), nil
+	}
+
+	newWidth, newHeight := bounds.Dx(), bounds.Dy()
+	if expand {
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		sin, cos = math.Abs(sin), math.Abs(cos)
+		// Round away the floating point noise before taking the ceiling.
+		newWidth = int(math.Ceil(math.Round((w*cos+h*sin)*1e6) / 1e6))
+		newHeight = int(math.Ceil(math.Round((w*sin+h*cos)*1e6) / 1e6))
+	}
+
+	m := Translate(-w/2, -h/2).Then(Rotation(angle)).Then(Translate(float64(newWidth)/2, float64(newHeight)/2))
+	return AffineContext(ctx, img, m, newWidth, newHeight, opts, progress)
+}
+
+// RotateStrict works like Rotate but returns an error for an unknown filter.
+//
+// Parameters:
+//   - img: The input image to be rotated
+//   - angle: The rotation in degrees
+//   - expand: Grow the result to hold the whole rotated image
+//   - opts: The filter and the colour of the uncovered corners
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError listing the supported filters
+func RotateStrict(img image.Image, angle float64, expand bool, opts TransformOptions) (image.Image, error) {
+	return RotateStrictContext(context.Background(), img, angle, expand, opts, nil)
+}
+
+// RotateStrictContext works like RotateStrict but aborts as soon as ctx is
+// cancelled and reports progress like RotateContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be rotated
+//   - angle: The rotation in degrees
+//   - expand: Grow the result to hold the whole rotated image
+//   - opts: The filter and the colour of the uncovered corners
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The rotated image, nil when cancelled or invalid
+//   - error: A *utils.ValidationError listing the supported filters, or ctx.Err() when the
+//     context was cancelled
+func RotateStrictContext(ctx context.Context, img image.Image, angle float64, expand bool, opts TransformOptions, progress utils.ProgressFunc) (image.Image, error) {
+	if opts.Filter != "" {
+		if err := utils.ValidateOption("filter", opts.Filter, resize.Filters()); err != nil {
+			return nil, err
+		}
+	}
+	return RotateContext(ctx, img, angle, expand, opts, progress)
+}
@@ -2,6 +2,8 @@ package flip
 
 import (
 	"image"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 )
 
 // FlipHorizontal flips the given image horizontally (mirror image along the vertical axis).
@@ -18,12 +20,14 @@ func FlipHorizontal(img image.Image) image.Image {
 
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
+	writer := pixel.NewWriter(newImage)
 
-	for y := 0; y < bounds.Max.Y; y++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		newX := bounds.Max.X - 1
-		for x := 0; x < bounds.Max.X; x++ {
-			pixel := img.At(newX, y)
-			newImage.Set(x, y, pixel)
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(newX, y)
+			writer.SetRGBA(x, y, r, g, b, a)
 			newX--
 		}
 	}
@@ -45,12 +49,14 @@ func FlipVertical(img image.Image) image.Image {
 
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
+	writer := pixel.NewWriter(newImage)
 
-	for x := 0; x < bounds.Max.X; x++ {
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
 		newY := bounds.Max.Y - 1
-		for y := 0; y < bounds.Max.Y; y++ {
-			pixel := img.At(x, newY)
-			newImage.Set(x, y, pixel)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			r, g, b, a := reader.RGBA(x, newY)
+			writer.SetRGBA(x, y, r, g, b, a)
 			newY--
 		}
 	}
@@ -0,0 +1,91 @@
+package flip
+
+import (
+	"image"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+)
+
+// remap copies every pixel of img to the position returned by to, which
+// receives the coordinates relative to the top left corner of img. The result
+// has the given size and starts at the origin of img.
+func remap(img image.Image, size image.Point, to func(x, y int) (int, int)) image.Image {
+	bounds := img.Bounds()
+	newImage := image.NewRGBA64(image.Rectangle{bounds.Min, bounds.Min.Add(size)})
+	reader := pixel.NewReader(img)
+	writer := pixel.NewWriter(newImage)
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			r, g, b, a := reader.RGBA(bounds.Min.X+x, bounds.Min.Y+y)
+			newX, newY := to(x, y)
+			writer.SetRGBA(bounds.Min.X+newX, bounds.Min.Y+newY, r, g, b, a)
+		}
+	}
+
+	return newImage
+}
+
+// Rotate90 rotates the image 90 degrees clockwise, moving pixels without
+// interpolation.
+//
+// Parameters:
+//   - img: The input image to be rotated
+//
+// Returns:
+//   - image.Image: A new RGBA64 image with the width and height of img swapped
+func Rotate90(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, image.Point{h, w}, func(x, y int) (int, int) { return h - 1 - y, x })
+}
+
+// Rotate180 rotates the image 180 degrees, moving pixels without interpolation.
+//
+// Parameters:
+//   - img: The input image to be rotated
+//
+// Returns:
+//   - image.Image: A new RGBA64 image with the size of img
+func Rotate180(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, image.Point{w, h}, func(x, y int) (int, int) { return w - 1 - x, h - 1 - y })
+}
+
+// Rotate270 rotates the image 270 degrees clockwise (90 degrees
+// counter-clockwise), moving pixels without interpolation.
+//
+// Parameters:
+//   - img: The input image to be rotated
+//
+// Returns:
+//   - image.Image: A new RGBA64 image with the width and height of img swapped
+func Rotate270(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, image.Point{h, w}, func(x, y int) (int, int) { return y, w - 1 - x })
+}
+
+// Transpose mirrors the image along its main diagonal, the top left pixel
+// staying in place. It equals a horizontal flip followed by Rotate270.
+//
+// Parameters:
+//   - img: The input image to be transposed
+//
+// Returns:
+//   - image.Image: A new RGBA64 image with the width and height of img swapped
+func Transpose(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, image.Point{h, w}, func(x, y int) (int, int) { return y, x })
+}
+
+// Transverse mirrors the image along its anti-diagonal, the top left pixel
+// moving to the bottom right. It equals a horizontal flip followed by Rotate90.
+//
+// Parameters:
+//   - img: The input image to be transversed
+//
+// Returns:
+//   - image.Image: A new RGBA64 image with the width and height of img swapped
+func Transverse(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	return remap(img, image.Point{h, w}, func(x, y int) (int, int) { return h - 1 - y, w - 1 - x })
+}
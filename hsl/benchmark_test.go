@@ -0,0 +1,15 @@
+package hsl_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/effects/effectstest"
+	"github.com/BrunoPoiano/imgeffects/hsl"
+)
+
+func BenchmarkHue(b *testing.B) {
+	effectstest.BenchmarkPixelAccess(b, func(img image.Image) image.Image {
+		return hsl.Hue(img, 180)
+	})
+}
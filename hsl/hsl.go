@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"math"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -104,15 +105,17 @@ func Hue(img image.Image, change int) image.Image {
 	change = utils.ClampGeneric(change, 0, 360)
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			h, s, l := RGBToHSL(r, g, b)
 			h = float64((int(h) + change) % 360)
 			rr, gg, bb := HSLToRGB(h, s, l)
 
-			newImage.Set(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
+			newImage.SetNRGBA64(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
 		}
 	}
 
@@ -132,16 +135,18 @@ func Hue(img image.Image, change int) image.Image {
 func Saturation(img image.Image, change float64) image.Image {
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 	change = math.Max(-1, math.Min(1, change))
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			h, s, l := RGBToHSL(r, g, b)
 			s = math.Max(0, math.Min(1, s*(1+change)))
 			rr, gg, bb := HSLToRGB(h, s, l)
 
-			newImage.Set(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
+			newImage.SetNRGBA64(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
 		}
 	}
 
@@ -162,16 +167,18 @@ func Luminance(img image.Image, change float64) image.Image {
 
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 	change = math.Max(-1, math.Min(1, change))
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			h, s, l := RGBToHSL(r, g, b)
 			l = math.Max(0, math.Min(1, l*(1+change)))
 			rr, gg, bb := HSLToRGB(h, s, l)
 
-			newImage.Set(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
+			newImage.SetNRGBA64(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
 		}
 	}
 
@@ -0,0 +1,148 @@
+package imageio
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// JPEGQuality is the quality used when encoding JPEG files (1-100).
+var JPEGQuality = 90
+
+// Load decodes the PNG, JPEG or GIF image stored at path. JPEG images are
+// turned upright according to their EXIF orientation unless AutoOrient is false.
+//
+// Parameters:
+//   - path: The image file path
+//
+// Returns:
+//   - image.Image: The decoded image
+//   - string: The format name reported by the decoder ("png", "jpeg" or "gif")
+//   - error
+func Load(path string) (image.Image, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	img, format, err := Decode(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("imageio: decoding %s: %w", path, err)
+	}
+
+	return img, format, nil
+}
+
+// Decode decodes a PNG, JPEG or GIF image from r like Load. The EXIF
+// orientation of a JPEG image is read from its metadata segments first, only
+// those being kept in memory for the decoder. A missing or malformed
+// orientation leaves the image as stored.
+//
+// Parameters:
+//   - r: The encoded image
+//
+// Returns:
+//   - image.Image: The decoded image
+//   - string: The format name reported by the decoder ("png", "jpeg" or "gif")
+//   - error
+func Decode(r io.Reader) (image.Image, string, error) {
+	if !AutoOrient {
+		return image.Decode(r)
+	}
+
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); !bytes.Equal(magic, []byte{0xff, 0xd8}) {
+		return image.Decode(br)
+	}
+
+	// The metadata segments read for the orientation are replayed to the
+	// decoder, followed by the rest of the file.
+	var header bytes.Buffer
+	orientation, err := ReadOrientation(io.TeeReader(br, &header))
+	if err != nil {
+		orientation = 1
+	}
+	img, format, err := image.Decode(io.MultiReader(&header, br))
+	if err != nil {
+		return nil, "", err
+	}
+	return ApplyOrientation(img, orientation), format, nil
+}
+
+// FormatFromPath returns the image format matching the extension of path.
+//
+// Parameters:
+//   - path: A file path ending in .png, .jpg, .jpeg or .gif (case-insensitive)
+//
+// Returns:
+//   - string: "png", "jpeg" or "gif"
+//   - error: When the extension is not supported
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "png", nil
+	case ".jpg", ".jpeg":
+		return "jpeg", nil
+	case ".gif":
+		return "gif", nil
+	default:
+		return "", fmt.Errorf("imageio: unsupported output extension %q", filepath.Ext(path))
+	}
+}
+
+// Encode writes img to w in the given format.
+//
+// Parameters:
+//   - w: The destination writer
+//   - img: The image to encode
+//   - format: "png", "jpeg" or "gif"
+//
+// Returns:
+//   - error
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "png":
+		return png.Encode(w, img)
+	case "jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: JPEGQuality})
+	case "gif":
+		return gif.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("imageio: unsupported format %q", format)
+	}
+}
+
+// Save encodes img to path using the format matching the file extension.
+//
+// Parameters:
+//   - path: The destination file path, created or truncated
+//   - img: The image to encode
+//
+// Returns:
+//   - error
+func Save(path string, img image.Image) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := Encode(file, img, format); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
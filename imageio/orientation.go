@@ -0,0 +1,183 @@
+package imageio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/BrunoPoiano/imgeffects/flip"
+)
+
+// AutoOrient makes Load and Decode turn JPEG images upright according to
+// their EXIF Orientation tag.
+var AutoOrient = true
+
+// ErrInvalidJPEG is returned by ReadOrientation when the data does not start
+// like a JPEG file.
+var ErrInvalidJPEG = errors.New("imageio: not a JPEG file")
+
+// orientationTag is the EXIF tag holding the orientation, in IFD0.
+const orientationTag = 0x0112
+
+// ReadOrientation returns the EXIF Orientation tag of a JPEG file, read from
+// its APP1 segments. The value tells how the stored pixels must be turned to
+// be displayed upright:
+//   - 1: Upright
+//   - 2: Needs a horizontal flip
+//   - 3: Needs a 180 degree rotation
+//   - 4: Needs a vertical flip
+//   - 5: Needs a transpose (mirror along the main diagonal)
+//   - 6: Needs a 90 degree clockwise rotation
+//   - 7: Needs a transverse (mirror along the anti-diagonal)
+//   - 8: Needs a 90 degree counter-clockwise rotation
+//
+// Parameters:
+//   - r: The JPEG data, read up to the start of the image data
+//
+// Returns:
+//   - int: The orientation (1-8), 1 when the file has no valid orientation tag
+//   - error: ErrInvalidJPEG, or the read error when the segments are truncated
+func ReadOrientation(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xff, 0xd8} {
+		return 1, ErrInvalidJPEG
+	}
+
+	for {
+		marker, err := readMarker(br)
+		if err != nil {
+			return 1, err
+		}
+		// Start of scan or end of image, the metadata segments are over.
+		if marker == 0xda || marker == 0xd9 {
+			return 1, nil
+		}
+		// Markers without a segment.
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(br, length[:]); err != nil {
+			return 1, err
+		}
+		size := int(binary.BigEndian.Uint16(length[:])) - 2
+		if size < 0 {
+			return 1, ErrInvalidJPEG
+		}
+
+		if marker != 0xe1 {
+			if _, err := br.Discard(size); err != nil {
+				return 1, err
+			}
+			continue
+		}
+
+		segment := make([]byte, size)
+		if _, err := io.ReadFull(br, segment); err != nil {
+			return 1, err
+		}
+		if orientation, ok := exifOrientation(segment); ok {
+			return orientation, nil
+		}
+	}
+}
+
+// readMarker skips to the next marker and returns its code.
+func readMarker(br *bufio.Reader) (byte, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xff {
+		return 0, fmt.Errorf("imageio: expected a JPEG marker, found %#x", b)
+	}
+	// Any number of 0xff fill bytes may precede the marker code.
+	for b == 0xff {
+		if b, err = br.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return b, nil
+}
+
+// exifOrientation reads the orientation tag of an APP1 segment, reporting
+// false when the segment is not EXIF or has no valid orientation.
+func exifOrientation(segment []byte) (int, bool) {
+	const header = "Exif\x00\x00"
+	if len(segment) < len(header)+8 || string(segment[:len(header)]) != header {
+		return 0, false
+	}
+	tiff := segment[len(header):]
+
+	var order binary.ByteOrder
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return 0, false
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0, false
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := range count {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return 0, false
+		}
+		if order.Uint16(tiff[entry:]) != orientationTag {
+			continue
+		}
+		// The orientation is a SHORT (type 3) with a count of 1, stored in
+		// the first two bytes of the value field.
+		if order.Uint16(tiff[entry+2:]) != 3 || order.Uint32(tiff[entry+4:]) != 1 {
+			return 0, false
+		}
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 0, false
+		}
+		return orientation, true
+	}
+	return 0, false
+}
+
+// ApplyOrientation turns an image stored with the given EXIF orientation
+// upright, using the matching operation of the flip package.
+//
+// Parameters:
+//   - img: The decoded image
+//   - orientation: The EXIF orientation (1-8), other values return img unchanged
+//
+// Returns:
+//   - image.Image: The upright image, img itself for orientation 1
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return flip.FlipHorizontal(img)
+	case 3:
+		return flip.Rotate180(img)
+	case 4:
+		return flip.FlipVertical(img)
+	case 5:
+		return flip.Transpose(img)
+	case 6:
+		return flip.Rotate90(img)
+	case 7:
+		return flip.Transverse(img)
+	case 8:
+		return flip.Rotate270(img)
+	default:
+		return img
+	}
+}
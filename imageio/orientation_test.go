@@ -0,0 +1,178 @@
+package imageio_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/imageio"
+)
+
+// entry is an IFD entry of a TIFF header.
+type entry struct {
+	tag, kind uint16
+	count     uint32
+	value     uint16
+}
+
+// exif returns the payload of an EXIF APP1 segment holding the entries in
+// IFD0, in the given byte order.
+func exif(order binary.AppendByteOrder, entries ...entry) []byte {
+	var tiff []byte
+	if order == binary.AppendByteOrder(binary.LittleEndian) {
+		tiff = []byte("II*\x00")
+	} else {
+		tiff = []byte("MM\x00*")
+	}
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, uint16(len(entries)))
+	for _, e := range entries {
+		tiff = order.AppendUint16(tiff, e.tag)
+		tiff = order.AppendUint16(tiff, e.kind)
+		tiff = order.AppendUint32(tiff, e.count)
+		tiff = order.AppendUint16(tiff, e.value)
+		tiff = append(tiff, 0, 0)
+	}
+	tiff = order.AppendUint32(tiff, 0)
+	return append([]byte("Exif\x00\x00"), tiff...)
+}
+
+// orientation is the entry of an Orientation tag.
+func orientation(value uint16) entry {
+	return entry{tag: 0x0112, kind: 3, count: 1, value: value}
+}
+
+// segment returns a JPEG marker segment.
+func segment(marker byte, payload []byte) []byte {
+	return append(binary.BigEndian.AppendUint16([]byte{0xff, marker}, uint16(len(payload)+2)), payload...)
+}
+
+// withSegments inserts the segments right after the SOI marker of a JPEG file.
+func withSegments(data []byte, segments ...[]byte) []byte {
+	out := append([]byte{}, data[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, data[2:]...)
+}
+
+// header returns the start of a JPEG file made of the segments, up to a
+// start of scan marker.
+func header(segments ...[]byte) []byte {
+	return withSegments([]byte{0xff, 0xd8, 0xff, 0xda}, segments...)
+}
+
+func TestReadOrientation(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want int
+		err  error
+	}{
+		{"little endian", header(segment(0xe1, exif(binary.LittleEndian, orientation(6)))), 6, nil},
+		{"big endian", header(segment(0xe1, exif(binary.BigEndian, orientation(8)))), 8, nil},
+		{"after other tags", header(segment(0xe1, exif(binary.BigEndian, entry{0x010f, 2, 4, 0}, orientation(3)))), 3, nil},
+		{"after other segments", header(segment(0xe0, []byte("JFIF\x00")), segment(0xe1, []byte("http://ns.adobe.com/xap/1.0/\x00")), segment(0xe1, exif(binary.LittleEndian, orientation(5)))), 5, nil},
+		{"fill bytes", withSegments([]byte{0xff, 0xd8, 0xff, 0xff, 0xda}, []byte{0xff}, segment(0xe1, exif(binary.LittleEndian, orientation(2)))), 2, nil},
+		{"no exif", header(segment(0xe0, []byte("JFIF\x00"))), 1, nil},
+		{"missing tag", header(segment(0xe1, exif(binary.LittleEndian, entry{0x010f, 2, 4, 0}))), 1, nil},
+		{"wrong type", header(segment(0xe1, exif(binary.LittleEndian, entry{0x0112, 4, 1, 6}))), 1, nil},
+		{"out of range", header(segment(0xe1, exif(binary.BigEndian, orientation(9)))), 1, nil},
+		{"bad byte order", header(segment(0xe1, append([]byte("Exif\x00\x00XX"), make([]byte, 12)...))), 1, nil},
+		{"truncated ifd", header(segment(0xe1, exif(binary.LittleEndian, orientation(6))[:6+8+2+6])), 1, nil},
+		{"ifd offset past the end", header(segment(0xe1, []byte("Exif\x00\x00II*\x00\xff\x00\x00\x00"))), 1, nil},
+		{"truncated segment", []byte{0xff, 0xd8, 0xff, 0xe1, 0x01, 0x00, 'E', 'x'}, 1, io.ErrUnexpectedEOF},
+		{"truncated length", []byte{0xff, 0xd8, 0xff, 0xe1, 0x01}, 1, io.ErrUnexpectedEOF},
+		{"no marker", []byte{0xff, 0xd8}, 1, io.EOF},
+		{"not a jpeg", []byte("\x89PNG\r\n\x1a\n"), 1, imageio.ErrInvalidJPEG},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := imageio.ReadOrientation(bytes.NewReader(tc.data))
+			if got != tc.want || !errors.Is(err, tc.err) {
+				t.Errorf("got %d, %v, want %d, %v", got, err, tc.want, tc.err)
+			}
+		})
+	}
+}
+
+// TestDecodeOrientation decodes a JPEG file stored with every orientation
+// and checks where its red top left block ends up.
+func TestDecodeOrientation(t *testing.T) {
+	const width, height = 32, 24
+	stored := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			c := color.RGBA{0, 0, 255, 255}
+			if x < width/2 && y < height/2 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			stored.Set(x, y, c)
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, stored, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := jpeg.Decode(bytes.NewReader(encoded.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The corner holding the red block once upright: left, top.
+	corners := map[int][2]bool{
+		1: {true, true}, 2: {false, true}, 3: {false, false}, 4: {true, false},
+		5: {true, true}, 6: {false, true}, 7: {false, false}, 8: {true, false},
+	}
+	for o := 1; o <= 8; o++ {
+		for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+			data := withSegments(encoded.Bytes(), segment(0xe1, exif(order, orientation(uint16(o)))))
+			img, format, err := imageio.Decode(bytes.NewReader(data))
+			if err != nil || format != "jpeg" {
+				t.Fatalf("orientation %d: %v, format %q", o, err, format)
+			}
+
+			want := imageio.ApplyOrientation(plain, o)
+			if img.Bounds() != want.Bounds() {
+				t.Fatalf("orientation %d: bounds %v, want %v", o, img.Bounds(), want.Bounds())
+			}
+			for y := want.Bounds().Min.Y; y < want.Bounds().Max.Y; y++ {
+				for x := want.Bounds().Min.X; x < want.Bounds().Max.X; x++ {
+					if img.At(x, y) != want.At(x, y) {
+						t.Fatalf("orientation %d: (%d, %d) is %v, want %v", o, x, y, img.At(x, y), want.At(x, y))
+					}
+				}
+			}
+
+			if (o >= 5) != (img.Bounds().Dx() == height) {
+				t.Errorf("orientation %d: size %v", o, img.Bounds().Size())
+			}
+			bounds := img.Bounds()
+			x, y := bounds.Max.X-2, bounds.Max.Y-2
+			if corners[o][0] {
+				x = bounds.Min.X + 1
+			}
+			if corners[o][1] {
+				y = bounds.Min.Y + 1
+			}
+			if r, _, b, _ := img.At(x, y).RGBA(); r < 0xc000 || b > 0x4000 {
+				t.Errorf("orientation %d: corner (%d, %d) is %v, want red", o, x, y, img.At(x, y))
+			}
+		}
+	}
+
+	imageio.AutoOrient = false
+	defer func() { imageio.AutoOrient = true }()
+	data := withSegments(encoded.Bytes(), segment(0xe1, exif(binary.LittleEndian, orientation(6))))
+	img, _, err := imageio.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != stored.Bounds() {
+		t.Errorf("AutoOrient false: bounds %v, want the stored %v", img.Bounds(), stored.Bounds())
+	}
+}
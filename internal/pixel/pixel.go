@@ -0,0 +1,281 @@
+// Package pixel provides allocation free access to the pixels of the concrete
+// image types of the standard library.
+//
+// Reading through image.Image.At boxes a color.Color for every pixel, which
+// dominates the runtime of most effects. The readers and writers of this
+// package detect *image.RGBA, *image.NRGBA, *image.RGBA64, *image.NRGBA64,
+// *image.Gray, *image.Gray16 and *image.YCbCr and access their Pix slices
+// directly, falling back to the generic methods for other image types.
+package pixel
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Reader reads pixels as premultiplied 16-bit values.
+type Reader interface {
+	// RGBA returns the premultiplied red, green, blue and alpha values of the
+	// pixel at (x, y) in the range [0, 65535], exactly like img.At(x, y).RGBA(),
+	// including for pixels outside the image bounds.
+	RGBA(x, y int) (r, g, b, a uint32)
+}
+
+// NewReader returns a Reader for img using the fastest access path available.
+//
+// Parameters:
+//   - img: The image to read from
+//
+// Returns:
+//   - Reader
+func NewReader(img image.Image) Reader {
+	switch img := img.(type) {
+	case *image.RGBA:
+		return rgbaReader{img}
+	case *image.NRGBA:
+		return nrgbaReader{img}
+	case *image.RGBA64:
+		return rgba64Reader{img}
+	case *image.NRGBA64:
+		return nrgba64Reader{img}
+	case *image.Gray:
+		return grayReader{img}
+	case *image.Gray16:
+		return gray16Reader{img}
+	case *image.YCbCr:
+		return ycbcrReader{img}
+	case image.RGBA64Image:
+		return rgba64ImageReader{img}
+	default:
+		return genericReader{img}
+	}
+}
+
+type rgbaReader struct{ img *image.RGBA }
+
+func (p rgbaReader) RGBA(x, y int) (r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return 0, 0, 0, 0
+	}
+	i := p.img.PixOffset(x, y)
+	s := p.img.Pix[i : i+4 : i+4]
+	return uint32(s[0]) * 0x101, uint32(s[1]) * 0x101, uint32(s[2]) * 0x101, uint32(s[3]) * 0x101
+}
+
+type nrgbaReader struct{ img *image.NRGBA }
+
+func (p nrgbaReader) RGBA(x, y int) (r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return 0, 0, 0, 0
+	}
+	i := p.img.PixOffset(x, y)
+	s := p.img.Pix[i : i+4 : i+4]
+	a = uint32(s[3]) * 0x101
+	r = uint32(s[0]) * 0x101 * a / 0xffff
+	g = uint32(s[1]) * 0x101 * a / 0xffff
+	b = uint32(s[2]) * 0x101 * a / 0xffff
+	return r, g, b, a
+}
+
+type rgba64Reader struct{ img *image.RGBA64 }
+
+func (p rgba64Reader) RGBA(x, y int) (r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return 0, 0, 0, 0
+	}
+	i := p.img.PixOffset(x, y)
+	s := p.img.Pix[i : i+8 : i+8]
+	r = uint32(s[0])<<8 | uint32(s[1])
+	g = uint32(s[2])<<8 | uint32(s[3])
+	b = uint32(s[4])<<8 | uint32(s[5])
+	a = uint32(s[6])<<8 | uint32(s[7])
+	return r, g, b, a
+}
+
+type nrgba64Reader struct{ img *image.NRGBA64 }
+
+func (p nrgba64Reader) RGBA(x, y int) (r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return 0, 0, 0, 0
+	}
+	i := p.img.PixOffset(x, y)
+	s := p.img.Pix[i : i+8 : i+8]
+	a = uint32(s[6])<<8 | uint32(s[7])
+	r = (uint32(s[0])<<8 | uint32(s[1])) * a / 0xffff
+	g = (uint32(s[2])<<8 | uint32(s[3])) * a / 0xffff
+	b = (uint32(s[4])<<8 | uint32(s[5])) * a / 0xffff
+	return r, g, b, a
+}
+
+type grayReader struct{ img *image.Gray }
+
+func (p grayReader) RGBA(x, y int) (r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		// image.Gray returns the zero color.Gray, which is opaque black.
+		return 0, 0, 0, 0xffff
+	}
+	v := uint32(p.img.Pix[p.img.PixOffset(x, y)]) * 0x101
+	return v, v, v, 0xffff
+}
+
+type gray16Reader struct{ img *image.Gray16 }
+
+func (p gray16Reader) RGBA(x, y int) (r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return 0, 0, 0, 0xffff
+	}
+	i := p.img.PixOffset(x, y)
+	v := uint32(p.img.Pix[i])<<8 | uint32(p.img.Pix[i+1])
+	return v, v, v, 0xffff
+}
+
+type ycbcrReader struct{ img *image.YCbCr }
+
+func (p ycbcrReader) RGBA(x, y int) (r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		// image.YCbCr returns the zero color.YCbCr, which is opaque.
+		return color.YCbCr{}.RGBA()
+	}
+	yi := p.img.YOffset(x, y)
+	ci := p.img.COffset(x, y)
+	return color.YCbCr{Y: p.img.Y[yi], Cb: p.img.Cb[ci], Cr: p.img.Cr[ci]}.RGBA()
+}
+
+type rgba64ImageReader struct{ img image.RGBA64Image }
+
+func (p rgba64ImageReader) RGBA(x, y int) (r, g, b, a uint32) {
+	c := p.img.RGBA64At(x, y)
+	return uint32(c.R), uint32(c.G), uint32(c.B), uint32(c.A)
+}
+
+type genericReader struct{ img image.Image }
+
+func (p genericReader) RGBA(x, y int) (r, g, b, a uint32) {
+	return p.img.At(x, y).RGBA()
+}
+
+// Writer stores pixels given as premultiplied 16-bit values.
+type Writer interface {
+	// SetRGBA stores the premultiplied red, green, blue and alpha values, in the
+	// range [0, 65535], at (x, y) converting them to the image colour model the
+	// same way img.Set(x, y, color.RGBA64{...}) would. Pixels outside the image
+	// bounds are ignored.
+	SetRGBA(x, y int, r, g, b, a uint32)
+}
+
+// NewWriter returns a Writer for img using the fastest access path available.
+//
+// Parameters:
+//   - img: The image to write to
+//
+// Returns:
+//   - Writer
+func NewWriter(img draw.Image) Writer {
+	switch img := img.(type) {
+	case *image.RGBA64:
+		return rgba64Writer{img}
+	case *image.NRGBA64:
+		return nrgba64Writer{img}
+	case *image.RGBA:
+		return rgbaWriter{img}
+	case *image.NRGBA:
+		return nrgbaWriter{img}
+	case *image.Gray:
+		return grayWriter{img}
+	case draw.RGBA64Image:
+		return rgba64ImageWriter{img}
+	default:
+		return genericWriter{img}
+	}
+}
+
+type rgba64Writer struct{ img *image.RGBA64 }
+
+func (p rgba64Writer) SetRGBA(x, y int, r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return
+	}
+	i := p.img.PixOffset(x, y)
+	s := p.img.Pix[i : i+8 : i+8]
+	s[0], s[1] = uint8(r>>8), uint8(r)
+	s[2], s[3] = uint8(g>>8), uint8(g)
+	s[4], s[5] = uint8(b>>8), uint8(b)
+	s[6], s[7] = uint8(a>>8), uint8(a)
+}
+
+type nrgba64Writer struct{ img *image.NRGBA64 }
+
+func (p nrgba64Writer) SetRGBA(x, y int, r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return
+	}
+	switch a {
+	case 0:
+		r, g, b = 0, 0, 0
+	case 0xffff:
+	default:
+		r = r * 0xffff / a
+		g = g * 0xffff / a
+		b = b * 0xffff / a
+	}
+	i := p.img.PixOffset(x, y)
+	s := p.img.Pix[i : i+8 : i+8]
+	s[0], s[1] = uint8(r>>8), uint8(r)
+	s[2], s[3] = uint8(g>>8), uint8(g)
+	s[4], s[5] = uint8(b>>8), uint8(b)
+	s[6], s[7] = uint8(a>>8), uint8(a)
+}
+
+type rgbaWriter struct{ img *image.RGBA }
+
+func (p rgbaWriter) SetRGBA(x, y int, r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return
+	}
+	i := p.img.PixOffset(x, y)
+	s := p.img.Pix[i : i+4 : i+4]
+	s[0], s[1], s[2], s[3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+}
+
+type nrgbaWriter struct{ img *image.NRGBA }
+
+func (p nrgbaWriter) SetRGBA(x, y int, r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return
+	}
+	switch a {
+	case 0:
+		r, g, b = 0, 0, 0
+	case 0xffff:
+	default:
+		r = r * 0xffff / a
+		g = g * 0xffff / a
+		b = b * 0xffff / a
+	}
+	i := p.img.PixOffset(x, y)
+	s := p.img.Pix[i : i+4 : i+4]
+	s[0], s[1], s[2], s[3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+}
+
+type grayWriter struct{ img *image.Gray }
+
+func (p grayWriter) SetRGBA(x, y int, r, g, b, a uint32) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return
+	}
+	// Same weights as color.GrayModel.
+	p.img.Pix[p.img.PixOffset(x, y)] = uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 24)
+}
+
+type rgba64ImageWriter struct{ img draw.RGBA64Image }
+
+func (p rgba64ImageWriter) SetRGBA(x, y int, r, g, b, a uint32) {
+	p.img.SetRGBA64(x, y, color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)})
+}
+
+type genericWriter struct{ img draw.Image }
+
+func (p genericWriter) SetRGBA(x, y int, r, g, b, a uint32) {
+	p.img.Set(x, y, color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)})
+}
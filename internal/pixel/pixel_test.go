@@ -0,0 +1,234 @@
+package pixel_test
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+)
+
+const benchWidth, benchHeight = 512, 384
+
+// testRect has a negative origin, and testSubRect selects part of it, so the
+// offsets of the Pix slices are exercised.
+var (
+	testRect    = image.Rect(-5, -3, 7, 6)
+	testSubRect = image.Rect(-3, -2, 4, 5)
+)
+
+// testImages returns an empty image with the given bounds for every type
+// with a fast path, and for a few going through the generic methods.
+func testImages(rect image.Rectangle) map[string]draw.Image {
+	return map[string]draw.Image{
+		"rgba":     image.NewRGBA(rect),
+		"nrgba":    image.NewNRGBA(rect),
+		"rgba64":   image.NewRGBA64(rect),
+		"nrgba64":  image.NewNRGBA64(rect),
+		"gray":     image.NewGray(rect),
+		"gray16":   image.NewGray16(rect),
+		"alpha":    image.NewAlpha(rect),
+		"alpha16":  image.NewAlpha16(rect),
+		"paletted": image.NewPaletted(rect, palette.Plan9),
+		"cmyk":     image.NewCMYK(rect),
+	}
+}
+
+// testColor returns a colour of (x, y), semi-transparent or transparent for
+// some of the pixels.
+func testColor(x, y int) color.NRGBA64 {
+	alpha := []uint16{0xffff, 0x8000, 0, 0x1234}[((x+2*y)%4+4)%4]
+	return color.NRGBA64{uint16(x*4111 + 30000), uint16(y*7919 + 20000), uint16(x*y*997 + 1000), alpha}
+}
+
+// fill sets every pixel of img with its test colour.
+func fill(img draw.Image) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.Set(x, y, testColor(x, y))
+		}
+	}
+}
+
+// subImage returns the part of img within rect.
+func subImage(img draw.Image, rect image.Rectangle) image.Image {
+	return img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}).SubImage(rect)
+}
+
+// TestReader checks every Reader against At, inside and around the bounds of
+// images and of sub images with a negative origin.
+func TestReader(t *testing.T) {
+	check := func(t *testing.T, img image.Image) {
+		reader := pixel.NewReader(img)
+		area := img.Bounds().Inset(-2)
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				r, g, b, a := reader.RGBA(x, y)
+				wr, wg, wb, wa := img.At(x, y).RGBA()
+				if r != wr || g != wg || b != wb || a != wa {
+					t.Fatalf("(%d, %d): got %04x %04x %04x %04x, want %04x %04x %04x %04x", x, y, r, g, b, a, wr, wg, wb, wa)
+				}
+			}
+		}
+	}
+
+	images := map[string]image.Image{}
+	for name, img := range testImages(testRect) {
+		fill(img)
+		images[name] = img
+	}
+	for _, ratio := range []image.YCbCrSubsampleRatio{image.YCbCrSubsampleRatio444, image.YCbCrSubsampleRatio420} {
+		ycbcr := image.NewYCbCr(testRect, ratio)
+		for y := testRect.Min.Y; y < testRect.Max.Y; y++ {
+			for x := testRect.Min.X; x < testRect.Max.X; x++ {
+				c := testColor(x, y)
+				ycbcr.Y[ycbcr.YOffset(x, y)], ycbcr.Cb[ycbcr.COffset(x, y)], ycbcr.Cr[ycbcr.COffset(x, y)] = color.RGBToYCbCr(uint8(c.R>>8), uint8(c.G>>8), uint8(c.B>>8))
+			}
+		}
+		images["ycbcr"+ratio.String()] = ycbcr
+	}
+
+	for name, img := range images {
+		t.Run(name, func(t *testing.T) { check(t, img) })
+		if sub, ok := img.(interface {
+			SubImage(image.Rectangle) image.Image
+		}); ok {
+			t.Run(name+"/sub", func(t *testing.T) { check(t, sub.SubImage(testSubRect)) })
+		}
+	}
+}
+
+// TestWriter checks every Writer against Set on twin images, inside and
+// around the bounds of images and of sub images with a negative origin.
+func TestWriter(t *testing.T) {
+	check := func(t *testing.T, got, want draw.Image) {
+		writer := pixel.NewWriter(got)
+		area := got.Bounds().Inset(-2)
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				r, g, b, a := testColor(x, y).RGBA()
+				writer.SetRGBA(x, y, r, g, b, a)
+				want.Set(x, y, color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)})
+			}
+		}
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				if g, w := got.At(x, y), want.At(x, y); g != w {
+					t.Fatalf("(%d, %d): got %v, want %v", x, y, g, w)
+				}
+			}
+		}
+	}
+
+	wants := testImages(testRect)
+	for name, got := range testImages(testRect) {
+		t.Run(name, func(t *testing.T) { check(t, got, wants[name]) })
+	}
+	wants = testImages(testRect)
+	for name, got := range testImages(testRect) {
+		t.Run(name+"/sub", func(t *testing.T) {
+			check(t, subImage(got, testSubRect).(draw.Image), subImage(wants[name], testSubRect).(draw.Image))
+		})
+	}
+}
+
+// benchImages returns a gradient converted to every image type with a fast
+// path.
+func benchImages() map[string]image.Image {
+	rect := image.Rect(0, 0, benchWidth, benchHeight)
+	src := image.NewNRGBA(rect)
+	for y := range benchHeight {
+		for x := range benchWidth {
+			src.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), uint8(x + y), uint8(255 - x/4)})
+		}
+	}
+
+	images := map[string]image.Image{"nrgba": src}
+	for name, dst := range map[string]draw.Image{
+		"rgba":    image.NewRGBA(rect),
+		"rgba64":  image.NewRGBA64(rect),
+		"nrgba64": image.NewNRGBA64(rect),
+		"gray":    image.NewGray(rect),
+		"gray16":  image.NewGray16(rect),
+	} {
+		draw.Draw(dst, rect, src, image.Point{}, draw.Src)
+		images[name] = dst
+	}
+	ycbcr := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+	for y := range benchHeight {
+		for x := range benchWidth {
+			c := src.NRGBAAt(x, y)
+			ycbcr.Y[ycbcr.YOffset(x, y)], ycbcr.Cb[ycbcr.COffset(x, y)], ycbcr.Cr[ycbcr.COffset(x, y)] = color.RGBToYCbCr(c.R, c.G, c.B)
+		}
+	}
+	images["ycbcr"] = ycbcr
+	return images
+}
+
+var sink uint32
+
+// BenchmarkReader compares reading every pixel through a Reader with
+// image.Image.At.
+func BenchmarkReader(b *testing.B) {
+	for name, img := range benchImages() {
+		b.Run(name+"/reader", func(b *testing.B) {
+			reader := pixel.NewReader(img)
+			for b.Loop() {
+				for y := range benchHeight {
+					for x := range benchWidth {
+						r, g, bl, a := reader.RGBA(x, y)
+						sink += r + g + bl + a
+					}
+				}
+			}
+		})
+		b.Run(name+"/at", func(b *testing.B) {
+			for b.Loop() {
+				for y := range benchHeight {
+					for x := range benchWidth {
+						r, g, bl, a := img.At(x, y).RGBA()
+						sink += r + g + bl + a
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWriter compares writing every pixel through a Writer with
+// draw.Image.Set.
+func BenchmarkWriter(b *testing.B) {
+	rect := image.Rect(0, 0, benchWidth, benchHeight)
+	for name, img := range map[string]draw.Image{
+		"rgba":    image.NewRGBA(rect),
+		"nrgba":   image.NewNRGBA(rect),
+		"rgba64":  image.NewRGBA64(rect),
+		"nrgba64": image.NewNRGBA64(rect),
+		"gray":    image.NewGray(rect),
+	} {
+		b.Run(name+"/writer", func(b *testing.B) {
+			writer := pixel.NewWriter(img)
+			for b.Loop() {
+				for y := range benchHeight {
+					for x := range benchWidth {
+						writer.SetRGBA(x, y, uint32(x)*0x80, uint32(y)*0x80, 0x4000, 0xffff)
+					}
+				}
+			}
+		})
+		b.Run(name+"/set", func(b *testing.B) {
+			for b.Loop() {
+				for y := range benchHeight {
+					for x := range benchWidth {
+						img.Set(x, y, color.RGBA64{uint16(x) * 0x80, uint16(y) * 0x80, 0x4000, 0xffff})
+					}
+				}
+			}
+		})
+	}
+}
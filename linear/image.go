@@ -0,0 +1,168 @@
+package linear
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+)
+
+// Image is an in-memory image of premultiplied linear-light float32 colours.
+// It implements image.Image and draw.Image, reading and writing sRGB colours
+// like the standard image types, and exposes the linear values with
+// LinearAt and SetLinear.
+//
+// Fields:
+//   - Pix: The R, G, B, A channels of the pixels, in row order starting at the top left
+//   - Stride: The Pix stride (in float32 values) between vertically adjacent pixels
+//   - Rect: The image bounds
+type Image struct {
+	Pix    []float32
+	Stride int
+	Rect   image.Rectangle
+}
+
+// NewImage returns a new transparent Image with the given bounds.
+//
+// Parameters:
+//   - r: The bounds of the image
+//
+// Returns:
+//   - *Image
+func NewImage(r image.Rectangle) *Image {
+	return &Image{
+		Pix:    make([]float32, 4*r.Dx()*r.Dy()),
+		Stride: 4 * r.Dx(),
+		Rect:   r,
+	}
+}
+
+// FromImage converts img to linear light. An *Image is returned as is.
+//
+// Parameters:
+//   - img: The image to convert
+//
+// Returns:
+//   - *Image: A new image with the bounds of img
+func FromImage(img image.Image) *Image {
+	if m, ok := img.(*Image); ok {
+		return m
+	}
+
+	bounds := img.Bounds()
+	newImage := NewImage(bounds)
+	reader := pixel.NewReader(img)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		i := newImage.PixOffset(bounds.Min.X, y)
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := FromRGBA(reader.RGBA(x, y))
+			s := newImage.Pix[i : i+4 : i+4]
+			s[0], s[1], s[2], s[3] = c.R, c.G, c.B, c.A
+			i += 4
+		}
+	}
+
+	return newImage
+}
+
+// ColorModel returns Model.
+func (p *Image) ColorModel() color.Model { return Model }
+
+// Bounds returns the image bounds.
+func (p *Image) Bounds() image.Rectangle { return p.Rect }
+
+// At returns the linear Color of the pixel at (x, y).
+func (p *Image) At(x, y int) color.Color {
+	return p.LinearAt(x, y)
+}
+
+// RGBA64At returns the pixel at (x, y) as premultiplied 16-bit sRGB values,
+// implementing image.RGBA64Image.
+func (p *Image) RGBA64At(x, y int) color.RGBA64 {
+	r, g, b, a := p.LinearAt(x, y).RGBA()
+	return color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+}
+
+// LinearAt returns the linear Color of the pixel at (x, y), transparent
+// outside the image bounds.
+func (p *Image) LinearAt(x, y int) Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return Color{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	return Color{s[0], s[1], s[2], s[3]}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds
+// to the pixel at (x, y).
+func (p *Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+// Set stores the colour c, converted to linear light, at (x, y).
+func (p *Image) Set(x, y int, c color.Color) {
+	p.SetLinear(x, y, Model.Convert(c).(Color))
+}
+
+// SetRGBA64 stores the premultiplied 16-bit sRGB colour c at (x, y),
+// implementing draw.RGBA64Image.
+func (p *Image) SetRGBA64(x, y int, c color.RGBA64) {
+	p.SetLinear(x, y, FromRGBA(uint32(c.R), uint32(c.G), uint32(c.B), uint32(c.A)))
+}
+
+// SetLinear stores the linear colour c at (x, y). Points outside the image
+// bounds are ignored.
+func (p *Image) SetLinear(x, y int, c Color) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	s[0], s[1], s[2], s[3] = c.R, c.G, c.B, c.A
+}
+
+// SubImage returns an image representing the portion of p visible through r.
+// The returned image shares pixels with p.
+func (p *Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return &Image{}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+	}
+}
+
+// Opaque reports whether every pixel of the image is fully opaque.
+func (p *Image) Opaque() bool {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		i := p.PixOffset(p.Rect.Min.X, y)
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			if p.Pix[i+3] < 1 {
+				return false
+			}
+			i += 4
+		}
+	}
+	return true
+}
+
+// ToSRGB converts the image back to an *image.RGBA64, e.g. before passing it
+// to code reading the Pix slices of the standard image types.
+//
+// Returns:
+//   - *image.RGBA64
+func (p *Image) ToSRGB() *image.RGBA64 {
+	newImage := image.NewRGBA64(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			newImage.SetRGBA64(x, y, p.RGBA64At(x, y))
+		}
+	}
+	return newImage
+}
@@ -0,0 +1,171 @@
+package mask
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// generate returns a width x height mask whose pixel centres, given in
+// fractions of the size, take the values of fn.
+func generate(width, height int, fn func(x, y float64) float64) image.Image {
+	newImage := image.NewGray16(image.Rect(0, 0, max(width, 0), max(height, 0)))
+	for y := range height {
+		for x := range width {
+			v := utils.ClampFloat64(fn((float64(x)+0.5)/float64(width), (float64(y)+0.5)/float64(height)), 0, 1)
+			newImage.SetGray16(x, y, color.Gray16{uint16(v*0xffff + 0.5)})
+		}
+	}
+	return newImage
+}
+
+// smoothstep eases t from 0 to 1 without the visible steps of a linear ramp
+// at its ends.
+func smoothstep(t float64) float64 {
+	t = utils.ClampFloat64(t, 0, 1)
+	return t * t * (3 - 2*t)
+}
+
+// LinearGradient returns a mask fading from black at the start point to
+// white at the end point, constant along the lines perpendicular to them.
+// The points are given in fractions of the size, (0, 0) being the top left
+// corner and (1, 1) the bottom right one.
+//
+// Parameters:
+//   - width, height: The size of the mask in pixels
+//   - x0, y0: The start point, black and beyond
+//   - x1, y1: The end point, white and beyond
+//
+// Returns:
+//   - image.Image: A new *image.Gray16
+//
+// Example:
+//
+//	// Black on the left edge, white on the right one.
+//	m := mask.LinearGradient(w, h, 0, 0.5, 1, 0.5)
+func LinearGradient(width, height int, x0, y0, x1, y1 float64) image.Image {
+	// The projection is measured in pixels, so diagonal gradients stay
+	// perpendicular to their direction on non-square masks.
+	w, h := float64(width), float64(height)
+	dx, dy := (x1-x0)*w, (y1-y0)*h
+	length := dx*dx + dy*dy
+	return generate(width, height, func(x, y float64) float64 {
+		if length == 0 {
+			return 1
+		}
+		return smoothstep(((x-x0)*w*dx + (y-y0)*h*dy) / length)
+	})
+}
+
+// RadialGradient returns a mask that is white inside a circle and fades to
+// black on a wider one. The radii are given in fractions of the half
+// diagonal, so 1 reaches the corners from the middle of the image. Swap the
+// radii, or use Invert, for a mask that is black in the middle, e.g. for a
+// vignette.
+//
+// Parameters:
+//   - width, height: The size of the mask in pixels
+//   - centerX, centerY: The centre in fractions of the size
+//   - inner: The radius of the white circle
+//   - outer: The radius where the mask becomes black
+//
+// Returns:
+//   - image.Image: A new *image.Gray16
+func RadialGradient(width, height int, centerX, centerY, inner, outer float64) image.Image {
+	w, h := float64(width), float64(height)
+	halfDiagonal := math.Hypot(w, h) / 2
+	return generate(width, height, func(x, y float64) float64 {
+		distance := math.Hypot((x-centerX)*w, (y-centerY)*h) / halfDiagonal
+		if inner == outer {
+			if distance <= inner {
+				return 1
+			}
+			return 0
+		}
+		return smoothstep((outer - distance) / (outer - inner))
+	})
+}
+
+// Band returns a mask that is black in a band across the image and fades to
+// white on both sides, the graduated mask of a tilt-shift.
+//
+// Parameters:
+//   - width, height: The size of the mask in pixels
+//   - center: The position of the middle of the band across its direction, in fractions of
+//     the height for a horizontal band (0 top, 1 bottom)
+//   - size: The width of the black part of the band, in the same fractions
+//   - feather: The width of the fade on each side, in the same fractions
+//   - angle: The clockwise rotation of the band in degrees, 0 being horizontal
+//
+// Returns:
+//   - image.Image: A new *image.Gray16
+func Band(width, height int, center, size, feather, angle float64) image.Image {
+	w, h := float64(width), float64(height)
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	// The distances across the band are measured in pixels from the centre
+	// of the image, and the fractions refer to the extent of the image in
+	// that direction.
+	extent := math.Abs(w*sin) + math.Abs(h*cos)
+	return generate(width, height, func(x, y float64) float64 {
+		across := ((y-0.5)*h*cos - (x-0.5)*w*sin) / extent
+		distance := math.Abs(across+0.5-center) - size/2
+		if feather <= 0 {
+			if distance > 0 {
+				return 1
+			}
+			return 0
+		}
+		return smoothstep(distance / feather)
+	})
+}
+
+// LuminanceRange returns a mask selecting the pixels of an image by their
+// luminance: white between low and high, fading to black over feather on
+// both sides. Transparent pixels are never selected.
+//
+// Parameters:
+//   - img: The image whose luminance is measured
+//   - low: The lowest selected luminance (0-1)
+//   - high: The highest selected luminance (0-1)
+//   - feather: The width of the fades (0-1), 0 gives hard edges
+//
+// Returns:
+//   - image.Image: A new *image.Gray16 with the bounds of img
+//
+// Example:
+//
+//	// Brighten the shadows only.
+//	shadows := mask.LuminanceRange(img, 0, 0.3, 0.1)
+//	newImage := mask.ApplyEffect(img, shadows, func(img image.Image) image.Image {
+//		return hsl.Luminance(img, 0.3)
+//	})
+func LuminanceRange(img image.Image, low, high, feather float64) image.Image {
+	bounds := img.Bounds()
+	reader := pixel.NewReader(img)
+	newImage := image.NewGray16(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			if a == 0 {
+				continue
+			}
+			r, g, b = utils.Unpremultiply(r, g, b, a)
+			luminance := (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 0xffff
+
+			var v float64
+			switch {
+			case luminance >= low && luminance <= high:
+				v = 1
+			case feather > 0:
+				v = smoothstep(1 - max(low-luminance, luminance-high)/feather)
+			}
+			v *= float64(a) / 0xffff
+			newImage.SetGray16(x, y, color.Gray16{uint16(v*0xffff + 0.5)})
+		}
+	}
+	return newImage
+}
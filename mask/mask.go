@@ -0,0 +1,199 @@
+// Package mask applies effects to parts of an image. A mask is a grayscale
+// image: white pixels take the processed image, black pixels keep the
+// original and the grays in between mix them. The generators build masks
+// from gradients or from the luminance of an image.
+package mask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/resize"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// ErrSizeMismatch is returned by ApplyEffectContext when the effect changes
+// the size of the image, e.g. a crop or a resize, which would leave the
+// processed image misaligned with the original and the mask.
+var ErrSizeMismatch = errors.New("mask: effect changed the image size")
+
+// weights returns the mask values in [0, 1] for an image of the given size,
+// row by row: the luminance of m times its alpha, m being stretched to the
+// size first when needed. An empty mask selects nothing.
+func weights(m image.Image, width, height int) []float64 {
+	values := make([]float64, width*height)
+	if m.Bounds().Empty() {
+		return values
+	}
+	// A uniform colour, e.g. image.White, covers any size.
+	if uniform, ok := m.(*image.Uniform); ok {
+		r, g, b, _ := uniform.RGBA()
+		v := min((0.2126*float64(r)+0.7152*float64(g)+0.0722*float64(b))/0xffff, 1)
+		for i := range values {
+			values[i] = v
+		}
+		return values
+	}
+	if m.Bounds().Dx() != width || m.Bounds().Dy() != height {
+		m = resize.Resample(m, width, height, resize.Bilinear)
+	}
+	bounds := m.Bounds()
+	reader := pixel.NewReader(m)
+
+	for y := range height {
+		for x := range width {
+			// The premultiplied luminance is the straight one times alpha.
+			r, g, b, _ := reader.RGBA(bounds.Min.X+x, bounds.Min.Y+y)
+			values[y*width+x] = min((0.2126*float64(r)+0.7152*float64(g)+0.0722*float64(b))/0xffff, 1)
+		}
+	}
+	return values
+}
+
+// Blend mixes two images through a mask: each pixel of the result is the
+// pixel of processed where the mask is white, the one of original where it
+// is black, and a mix of both in between.
+//
+// Parameters:
+//   - original: The image kept where the mask is black
+//   - processed: The image taken where the mask is white, read at the same offset from its
+//     top left corner. Outside its bounds the original is kept.
+//   - m: The mask, its luminance times its alpha giving the weight of processed.
+//     It is stretched to the size of original when the sizes differ.
+//
+// Returns:
+//   - image.Image: A new RGBA64 image with the bounds of original
+func Blend(original, processed, m image.Image) image.Image {
+	newImage, _ := BlendContext(context.Background(), original, processed, m, nil)
+	return newImage
+}
+
+// BlendContext works like Blend but aborts as soon as ctx is cancelled and
+// reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - original: The image kept where the mask is black
+//   - processed: The image taken where the mask is white
+//   - m: The mask
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The blended image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func BlendContext(ctx context.Context, original, processed, m image.Image, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := original.Bounds()
+	width := bounds.Dx()
+	values := weights(m, width, bounds.Dy())
+	originalReader := pixel.NewReader(original)
+	processedReader := pixel.NewReader(processed)
+	offset := processed.Bounds().Min.Sub(bounds.Min)
+
+	blendFunc := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				or, og, ob, oa := originalReader.RGBA(x, y)
+				w := values[(y-bounds.Min.Y)*width+x-bounds.Min.X]
+				if w == 0 || !(image.Point{x, y}.Add(offset).In(processed.Bounds())) {
+					newImage.SetRGBA64(x, y, color.RGBA64{uint16(or), uint16(og), uint16(ob), uint16(oa)})
+					continue
+				}
+
+				pr, pg, pb, pa := processedReader.RGBA(x+offset.X, y+offset.Y)
+				mix := func(o, p uint32) uint16 { return uint16(float64(o) + (float64(p)-float64(o))*w + 0.5) }
+				newImage.SetRGBA64(x, y, color.RGBA64{mix(or, pr), mix(og, pg), mix(ob, pb), mix(oa, pa)})
+			}
+		}
+	}
+	return utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{Image: original, Function: blendFunc, Progress: progress})
+}
+
+// ApplyEffect applies any effect to the parts of an image selected by a
+// mask, blending the result with the original like Blend.
+//
+// Parameters:
+//   - img: The input image
+//   - m: The mask, white where the effect is applied
+//   - effect: The effect applied to the whole image before the blend
+//
+// Returns:
+//   - image.Image
+//
+// Note: Use ApplyEffectContext to get an error instead of a misaligned blend when the
+// effect changes the size of the image.
+//
+// Example:
+//
+//	sky := mask.LinearGradient(w, h, 0.5, 0.6, 0.5, 0.2)
+//	newImage := mask.ApplyEffect(img, sky, func(img image.Image) image.Image {
+//		return hsl.Saturation(img, 0.4)
+//	})
+func ApplyEffect(img, m image.Image, effect func(image.Image) image.Image) image.Image {
+	return Blend(img, effect(img), m)
+}
+
+// ApplyEffectContext works like ApplyEffect for an effect supporting
+// cancellation. The total reported to progress covers the effect and the
+// blend, the effect reporting its own units scaled to the image height.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image
+//   - m: The mask, white where the effect is applied
+//   - effect: The cancellable effect, called with its own progress callback
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The masked result, nil when cancelled or when the effect fails
+//   - error: The error of the effect, ErrSizeMismatch when the effect changed the image
+//     size, or ctx.Err() when the context was cancelled
+func ApplyEffectContext(ctx context.Context, img, m image.Image, effect func(context.Context, image.Image, utils.ProgressFunc) (image.Image, error), progress utils.ProgressFunc) (image.Image, error) {
+	height := img.Bounds().Dy()
+	var effectProgress, blendProgress utils.ProgressFunc
+	if progress != nil {
+		effectProgress = func(done, total int) {
+			if total > 0 {
+				progress(done*height/total, 2*height)
+			}
+		}
+		blendProgress = func(done, total int) { progress(height+done, 2*height) }
+	}
+
+	processed, err := effect(ctx, img, effectProgress)
+	if err != nil {
+		return nil, err
+	}
+	if size := processed.Bounds().Size(); size != img.Bounds().Size() {
+		return nil, fmt.Errorf("%w: %v to %v", ErrSizeMismatch, img.Bounds().Size(), size)
+	}
+	return BlendContext(ctx, img, processed, m, blendProgress)
+}
+
+// Invert returns the negative of a mask, swapping the areas kept and
+// processed.
+//
+// Parameters:
+//   - m: The mask
+//
+// Returns:
+//   - image.Image: A new *image.Gray16 with the bounds of m
+func Invert(m image.Image) image.Image {
+	bounds := m.Bounds()
+	values := weights(m, bounds.Dx(), bounds.Dy())
+	newImage := image.NewGray16(bounds)
+	for y := range bounds.Dy() {
+		for x := range bounds.Dx() {
+			newImage.SetGray16(bounds.Min.X+x, bounds.Min.Y+y, color.Gray16{uint16((1-values[y*bounds.Dx()+x])*0xffff + 0.5)})
+		}
+	}
+	return newImage
+}
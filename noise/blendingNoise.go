@@ -3,11 +3,26 @@ package noise
 import (
 	"image"
 	"image/color"
+	"math/rand/v2"
 	"sync"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/linear"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
+// generateNoise returns the noise of the given type used by the blending functions.
+func generateNoise(noiseType string, width, height int, src rand.Source) image.Image {
+	switch noiseType {
+	case "gray":
+		return NoiseGeneratorGrayScaleWithSource(width, height, src)
+	case "color":
+		return NoiseGeneratorColorWithSource(width, height, src)
+	default:
+		return NoiseGeneratorWithSource(width, height, src)
+	}
+}
+
 // BlendingNoiseToImage applies a noise effect to an image by blending the original image
 // with a generated noise pattern.
 //
@@ -25,33 +40,46 @@ import (
 //
 // The function uses parallel processing to efficiently apply the noise effect.
 func BlendingNoiseToImage(img image.Image, alpha float64, noiseType string) image.Image {
+	return BlendingNoiseToImageWithSource(img, alpha, noiseType, nil)
+}
+
+// BlendingNoiseToImageWithSource works like BlendingNoiseToImage but generates the
+// noise from src. The noise is generated before the parallel blending, so the
+// result only depends on the source state.
+//
+// Parameters:
+//   - img: The input image to which noise will be applied
+//   - alpha: The blending factor, ranging from 0 to 1
+//   - noiseType: The type of noise to generate and apply: "gray", "color" or default
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image
+func BlendingNoiseToImageWithSource(img image.Image, alpha float64, noiseType string, src rand.Source) image.Image {
 
 	bounds := img.Bounds()
-	var noiseImage image.Image
+	noiseImage := generateNoise(noiseType, bounds.Dx(), bounds.Dy(), src)
 
-	switch noiseType {
-	case "gray":
-		noiseImage = NoiseGeneratorGrayScale(bounds.Max.X, bounds.Max.Y)
-	case "color":
-		noiseImage = NoiseGeneratorColor(bounds.Max.X, bounds.Max.Y)
-	default:
-		noiseImage = NoiseGenerator(bounds.Max.X, bounds.Max.Y)
-	}
+	reader := pixel.NewReader(img)
+	noiseReader := pixel.NewReader(noiseImage)
 
 	blendingFunc := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
 		defer wg.Done()
 
 		for y := start; y < end; y++ {
-			for x := 0; x < bounds.Max.X; x++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-				r1, g1, b1, a1 := img.At(x, y).RGBA()
-				r2, g2, b2, a2 := noiseImage.At(x, y).RGBA()
+				r1, g1, b1, a1 := reader.RGBA(x, y)
+				r2, g2, b2, _ := noiseReader.RGBA(x-bounds.Min.X, y-bounds.Min.Y)
 
+				// The noise is opaque, it is mixed into the colour of the pixel
+				// at the pixel opacity and the image keeps its alpha.
+				opacity := float64(a1) / 65535
 				color := color.RGBA64{
-					R: uint16((alpha * float64(r1)) + ((1 - alpha) * float64(r2))),
-					G: uint16((alpha * float64(g1)) + ((1 - alpha) * float64(g2))),
-					B: uint16((alpha * float64(b1)) + ((1 - alpha) * float64(b2))),
-					A: uint16((alpha * float64(a1)) + ((1 - alpha) * float64(a2))),
+					R: uint16((alpha * float64(r1)) + ((1 - alpha) * float64(r2) * opacity)),
+					G: uint16((alpha * float64(g1)) + ((1 - alpha) * float64(g2) * opacity)),
+					B: uint16((alpha * float64(b1)) + ((1 - alpha) * float64(b2) * opacity)),
+					A: uint16(a1),
 				}
 
 				newImage.SetRGBA64(x, y, color)
@@ -62,3 +90,48 @@ func BlendingNoiseToImage(img image.Image, alpha float64, noiseType string) imag
 	return utils.ParallelExecution(utils.ParallelExecutionStruct{Image: img, Function: blendingFunc})
 
 }
+
+// BlendingNoiseToImageLinear works like BlendingNoiseToImageWithSource but blends
+// the image and the noise in linear light, so the noise does not shift the
+// brightness of the image.
+//
+// Parameters:
+//   - img: The input image to which noise will be applied
+//   - alpha: The blending factor, ranging from 0 to 1
+//   - noiseType: The type of noise to generate and apply: "gray", "color" or default
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image: A *linear.Image
+func BlendingNoiseToImageLinear(img image.Image, alpha float64, noiseType string, src rand.Source) image.Image {
+
+	bounds := img.Bounds()
+	noiseImage := generateNoise(noiseType, bounds.Dx(), bounds.Dy(), src)
+
+	source := linear.FromImage(img)
+	noiseReader := pixel.NewReader(noiseImage)
+	newImage := linear.NewImage(bounds)
+	a1, a2 := float32(alpha), float32(1-alpha)
+
+	blendingFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+
+		for y := start; y < end; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+
+				c1 := source.LinearAt(x, y)
+				c2 := linear.FromRGBA(noiseReader.RGBA(x-bounds.Min.X, y-bounds.Min.Y))
+
+				newImage.SetLinear(x, y, linear.Color{
+					R: a1*c1.R + a2*c2.R*c1.A,
+					G: a1*c1.G + a2*c2.G*c1.A,
+					B: a1*c1.B + a2*c2.B*c1.A,
+					A: c1.A,
+				})
+			}
+		}
+	}
+
+	utils.ParallelExecution(utils.ParallelExecutionStruct{Image: img, Function: blendingFunc, NoOutput: true})
+	return newImage
+}
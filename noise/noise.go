@@ -18,6 +18,21 @@ import (
 // Returns:
 //   - image.Image
 func NoiseGeneratorColor(width, height int) image.Image {
+	return NoiseGeneratorColorWithSource(width, height, nil)
+}
+
+// NoiseGeneratorColorWithSource works like NoiseGeneratorColor but draws the color noise from src,
+// so the same source state always generates the same image.
+//
+// Parameters:
+//   - width: The width of the image in pixels
+//   - height: The height of the image in pixels
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image
+func NoiseGeneratorColorWithSource(width, height int, src rand.Source) image.Image {
+	random := utils.NewRand(src)
 
 	newImage := image.NewRGBA64(image.Rect(0, 0, width, height))
 
@@ -25,9 +40,9 @@ func NoiseGeneratorColor(width, height int) image.Image {
 		for x := 0; x < width; x++ {
 
 			color := color.RGBA64{
-				R: uint16(rand.Uint64N(65535)),
-				G: uint16(rand.Uint64N(65535)),
-				B: uint16(rand.Uint64N(65535)),
+				R: uint16(random.Uint64N(65535)),
+				G: uint16(random.Uint64N(65535)),
+				B: uint16(random.Uint64N(65535)),
 				A: 65535,
 			}
 
@@ -49,15 +64,30 @@ func NoiseGeneratorColor(width, height int) image.Image {
 // Returns:
 //   - image.Image
 func NoiseGenerator(width, height int) image.Image {
+	return NoiseGeneratorWithSource(width, height, nil)
+}
+
+// NoiseGeneratorWithSource works like NoiseGenerator but draws the black and white noise from src,
+// so the same source state always generates the same image.
+//
+// Parameters:
+//   - width: The width of the image in pixels
+//   - height: The height of the image in pixels
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image
+func NoiseGeneratorWithSource(width, height int, src rand.Source) image.Image {
+	random := utils.NewRand(src)
 
 	newImage := image.NewGray(image.Rect(0, 0, width, height))
 	treshold_level := (255 * 50) / 100
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 
-			r := uint32(rand.UintN(255))
-			g := uint32(rand.UintN(255))
-			b := uint32(rand.UintN(255))
+			r := uint32(random.UintN(255))
+			g := uint32(random.UintN(255))
+			b := uint32(random.UintN(255))
 
 			pixel := utils.Luminance16bit(r, g, b)
 			if pixel > float64(treshold_level) {
@@ -84,14 +114,29 @@ func NoiseGenerator(width, height int) image.Image {
 // Returns:
 //   - image.Image
 func NoiseGeneratorGrayScale(width, height int) image.Image {
+	return NoiseGeneratorGrayScaleWithSource(width, height, nil)
+}
+
+// NoiseGeneratorGrayScaleWithSource works like NoiseGeneratorGrayScale but draws the grayscale noise from src,
+// so the same source state always generates the same image.
+//
+// Parameters:
+//   - width: The width of the image in pixels
+//   - height: The height of the image in pixels
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image
+func NoiseGeneratorGrayScaleWithSource(width, height int, src rand.Source) image.Image {
+	random := utils.NewRand(src)
 
 	newImage := image.NewGray(image.Rect(0, 0, width, height))
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 
-			r := uint32(rand.UintN(255))
-			g := uint32(rand.UintN(255))
-			b := uint32(rand.UintN(255))
+			r := uint32(random.UintN(255))
+			g := uint32(random.UintN(255))
+			b := uint32(random.UintN(255))
 
 			pixel := utils.Luminance16bit(r, g, b)
 
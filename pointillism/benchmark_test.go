@@ -0,0 +1,21 @@
+package pointillism_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/effects/effectstest"
+	"github.com/BrunoPoiano/imgeffects/pointillism"
+)
+
+func BenchmarkHalftone(b *testing.B) {
+	effectstest.BenchmarkPixelAccess(b, func(img image.Image) image.Image {
+		return pointillism.Halftone(img, 8, true)
+	})
+}
+
+func BenchmarkPointillismGridBased(b *testing.B) {
+	effectstest.BenchmarkPixelAccess(b, func(img image.Image) image.Image {
+		return pointillism.PointillismGridBased(img, 5)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -23,27 +24,33 @@ func Halftone(img image.Image, dotSize int, useColor bool) image.Image {
 	dotSize = utils.ClampGeneric(dotSize, 1, 20)
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
-	for y := 0; y < bounds.Max.Y; y += dotSize {
-		for x := 0; x < bounds.Max.X; x += dotSize {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += dotSize {
+		for x := bounds.Min.X; x < bounds.Max.X; x += dotSize {
 
-			var grid []color.Color
+			var sumR, sumG, sumB, sumA, count uint32
 
 			for dy := 0; dy < dotSize; dy++ {
 				for dx := 0; dx < dotSize; dx++ {
 					px := x + dx
 					py := y + dy
 
-					if px < 0 || py < 0 || px >= bounds.Max.X || py >= bounds.Max.Y {
+					if px < bounds.Min.X || py < bounds.Min.Y || px >= bounds.Max.X || py >= bounds.Max.Y {
 						continue
 					}
-					grid = append(grid, img.At(px, py))
+					r, g, b, a := reader.RGBA(px, py)
+					sumR += r
+					sumG += g
+					sumB += b
+					sumA += a
+					count++
 				}
 			}
 
-			colorAverage := utils.ColorAverage(grid)
-			r, g, b, _ := colorAverage.RGBA()
-			gray := utils.Luminance16bit(r, g, b)
+			averageR, averageG, averageB := utils.Unpremultiply(sumR/count, sumG/count, sumB/count, sumA/count)
+			colorAverage := color.NRGBA64{uint16(averageR), uint16(averageG), uint16(averageB), uint16(sumA / count)}
+			gray := utils.Luminance16bit(averageR, averageG, averageB)
 
 			radius := int((1 - gray/65535) * float64(dotSize) / 2)
 			radiusCalc := radius * radius
@@ -57,9 +64,9 @@ func Halftone(img image.Image, dotSize int, useColor bool) image.Image {
 						px := centerX + dx
 						py := centerY + dy
 						if useColor {
-							newImage.Set(px, py, colorAverage)
+							newImage.SetNRGBA64(px, py, colorAverage)
 						} else {
-							newImage.Set(px, py, color.Black)
+							newImage.SetNRGBA64(px, py, color.NRGBA64{A: 0xffff})
 						}
 					}
 				}
@@ -86,28 +93,34 @@ func HalftoneDiagonal(img image.Image, dotSize int, useColor bool) image.Image {
 	dotSize = utils.ClampGeneric(dotSize, 1, 20)
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
 	xvalue := 0
-	for y := 0; y < bounds.Max.Y; y += dotSize {
-		for x := xvalue; x < bounds.Max.X; x += dotSize {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += dotSize {
+		for x := bounds.Min.X + xvalue; x < bounds.Max.X; x += dotSize {
 
-			var grid []color.Color
+			var sumR, sumG, sumB, sumA, count uint32
 
 			for dy := 0; dy < dotSize; dy++ {
 				for dx := 0; dx < dotSize; dx++ {
 					px := x + dx
 					py := y + dy
 
-					if px < 0 || py < 0 || px >= bounds.Max.X || py >= bounds.Max.Y {
+					if px < bounds.Min.X || py < bounds.Min.Y || px >= bounds.Max.X || py >= bounds.Max.Y {
 						continue
 					}
-					grid = append(grid, img.At(px, py))
+					r, g, b, a := reader.RGBA(px, py)
+					sumR += r
+					sumG += g
+					sumB += b
+					sumA += a
+					count++
 				}
 			}
 
-			colorAverage := utils.ColorAverage(grid)
-			r, g, b, _ := colorAverage.RGBA()
-			gray := utils.Luminance16bit(r, g, b)
+			averageR, averageG, averageB := utils.Unpremultiply(sumR/count, sumG/count, sumB/count, sumA/count)
+			colorAverage := color.NRGBA64{uint16(averageR), uint16(averageG), uint16(averageB), uint16(sumA / count)}
+			gray := utils.Luminance16bit(averageR, averageG, averageB)
 
 			radius := int((1 - gray/65535) * float64(dotSize) / 2)
 			radiusCalc := radius * radius
@@ -121,9 +134,9 @@ func HalftoneDiagonal(img image.Image, dotSize int, useColor bool) image.Image {
 						px := centerX + dx
 						py := centerY + dy
 						if useColor {
-							newImage.Set(px, py, colorAverage)
+							newImage.SetNRGBA64(px, py, colorAverage)
 						} else {
-							newImage.Set(px, py, color.Black)
+							newImage.SetNRGBA64(px, py, color.NRGBA64{A: 0xffff})
 						}
 					}
 				}
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -25,15 +26,16 @@ func PointillismGridBased(img image.Image, radius int) image.Image {
 	radius = utils.ClampGeneric(radius, 1, 20)
 	bounds := img.Bounds()
 	newImage := image.NewRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
 	edge := radius / 2
 	step := radius * 2
 	radius_calc := radius * radius
 
-	for y := 0; y < bounds.Max.Y; y += step {
-		for x := 0; x < bounds.Max.X; x += step {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
 
-			var grid []color.Color
+			var sumR, sumG, sumB, sumA, count uint32
 
 			startX := x - edge
 			startY := y - edge
@@ -44,13 +46,18 @@ func PointillismGridBased(img image.Image, radius int) image.Image {
 				for curX := startX; curX < endX; curX++ {
 					clampedX := utils.ClampGeneric(curX, bounds.Min.X, bounds.Max.X-1)
 					clampedY := utils.ClampGeneric(curY, bounds.Min.Y, bounds.Max.Y-1)
-					grid = append(grid, img.At(clampedX, clampedY))
+					r, g, b, a := reader.RGBA(clampedX, clampedY)
+					sumR += r
+					sumG += g
+					sumB += b
+					sumA += a
+					count++
 				}
 			}
 
 			var c color.Color
-			if len(grid) > 0 {
-				c = utils.ColorAverage(grid)
+			if count > 0 {
+				c = color.RGBA64{uint16(sumR / count), uint16(sumG / count), uint16(sumB / count), uint16(sumA / count)}
 			} else {
 				clampedX := utils.ClampGeneric(x, bounds.Min.X, bounds.Max.X-1)
 				clampedY := utils.ClampGeneric(y, bounds.Min.Y, bounds.Max.Y-1)
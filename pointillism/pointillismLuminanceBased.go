@@ -2,9 +2,12 @@ package pointillism
 
 import (
 	"image"
-	"math/rand"
+	"image/color"
+	"math/rand/v2"
 	"sync"
+	"sync/atomic"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -19,7 +22,7 @@ import (
 // Parameters:
 //   - img: The source image (image.Image) to apply the effect to.
 //   - points: The total number of circular points to draw. This value is clamped
-//     internally to a minimum of 10. Higher values result in a denser effect.
+//     internally between 10 and 100000000. Higher values result in a denser effect.
 //   - scaling: Controls the maximum size of the points and acts as a scaling factor
 //     in the luminance-to-radius calculation. Effectively sets the upper limit
 //     for the point radius (clamped between 1 and 30).
@@ -27,36 +30,104 @@ import (
 // Returns:
 //   - image.Image.
 func PointillismLuminanceBased(img image.Image, points, scaling int) image.Image {
+	return PointillismLuminanceBasedWithSource(img, points, scaling, nil)
+}
+
+// maxLuminancePoints is the largest number of points drawn by
+// PointillismLuminanceBased, the indexes of the points sharing a uint64 with
+// the position of their colour.
+const maxLuminancePoints = 100000000
+
+// luminanceBlock is the number of points generated from the same seed.
+const luminanceBlock = 1024
+
+// PointillismLuminanceBasedWithSource works like PointillismLuminanceBased but
+// places the points with src. The points are generated in blocks, each from a
+// seed drawn from src, and where points overlap the last one wins, so the same
+// source state always produces the same image whatever the number of
+// goroutines.
+//
+// Parameters:
+//   - img: The source image (image.Image) to apply the effect to.
+//   - points: The total number of circular points to draw (10-100000000).
+//   - scaling: The maximum size of the points (1-30).
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image.
+func PointillismLuminanceBasedWithSource(img image.Image, points, scaling int, src rand.Source) image.Image {
 	bounds := img.Bounds()
-	points = utils.ClampGeneric(points, 10, 99999999999999)
+	width := bounds.Dx()
+	points = utils.ClampGeneric(points, 10, maxLuminancePoints)
 	scaling = utils.ClampGeneric(scaling, 1, 30)
+	reader := pixel.NewReader(img)
+	if bounds.Empty() {
+		return image.NewRGBA64(bounds)
+	}
+	seed := utils.NewRand(src).Uint64()
 
-	pointFunc := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
+	// Each pixel keeps the index, plus one, of the last point covering it
+	// in the high bits and the offset of that point's centre in the low ones.
+	const offsetBits = 36
+	owners := make([]atomic.Uint64, width*bounds.Dy())
+
+	pointFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
 		defer wg.Done()
-		for i := start; i < end; i++ {
-			x := rand.Intn(bounds.Dx())
-			y := rand.Intn(bounds.Dy())
-			color := img.At(x, y)
-			r, g, b, _ := color.RGBA()
-
-			lum := utils.Luminance16bit(r, g, b) * float64(scaling)
-
-			radius := int(lum / (3 * 65535) * 5)
-			radius = utils.ClampGeneric(radius, 1, scaling)
-			if radius == 1 {
-				radius = rand.Intn(5)
-			}
-			radius_calc := radius * radius
+		for block := start; block < end; block++ {
+			random := rand.New(rand.NewPCG(seed, uint64(block)))
+			for i := block * luminanceBlock; i < min((block+1)*luminanceBlock, points); i++ {
+				px := random.IntN(width)
+				py := random.IntN(bounds.Dy())
+				r, g, b, a := reader.RGBA(bounds.Min.X+px, bounds.Min.Y+py)
+
+				sr, sg, sb := utils.Unpremultiply(r, g, b, a)
+				lum := utils.Luminance16bit(sr, sg, sb) * float64(scaling)
+
+				radius := int(lum / (3 * 65535) * 5)
+				radius = utils.ClampGeneric(radius, 1, scaling)
+				if radius == 1 {
+					radius = random.IntN(5)
+				}
+				radius_calc := radius * radius
+				key := uint64(i+1)<<offsetBits | uint64(py*width+px)
 
-			for dy := -radius; dy <= radius; dy++ {
-				for dx := -radius; dx <= radius; dx++ {
-					if dx*dx+dy*dy <= radius_calc {
-						newImage.Set(x+dx, y+dy, color)
+				for dy := max(-radius, -py); dy <= min(radius, bounds.Dy()-1-py); dy++ {
+					for dx := max(-radius, -px); dx <= min(radius, width-1-px); dx++ {
+						if dx*dx+dy*dy > radius_calc {
+							continue
+						}
+						owner := &owners[(py+dy)*width+px+dx]
+						for {
+							current := owner.Load()
+							if current >= key || owner.CompareAndSwap(current, key) {
+								break
+							}
+						}
 					}
 				}
 			}
 		}
 	}
+	utils.ParallelExecution(utils.ParallelExecutionStruct{
+		Image:    img,
+		Function: pointFunc,
+		EndSize:  (points + luminanceBlock - 1) / luminanceBlock,
+		NoOutput: true,
+	})
 
-	return utils.ParallelExecution(utils.ParallelExecutionStruct{Image: img, Function: pointFunc, EndSize: points})
+	drawFunc := func(start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				key := owners[(y-bounds.Min.Y)*width+x-bounds.Min.X].Load()
+				if key == 0 {
+					continue
+				}
+				centre := int(key & (1<<offsetBits - 1))
+				r, g, b, a := reader.RGBA(bounds.Min.X+centre%width, bounds.Min.Y+centre/width)
+				newImage.SetRGBA64(x, y, color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)})
+			}
+		}
+	}
+	return utils.ParallelExecution(utils.ParallelExecutionStruct{Image: img, Function: drawFunc})
 }
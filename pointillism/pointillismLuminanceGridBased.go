@@ -3,11 +3,46 @@ package pointillism
 import (
 	"image"
 	"image/color"
-	"math/rand"
+	"math/rand/v2"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
+// Direction is the traversal direction of PointillismLuminanceGridBased.
+type Direction string
+
+const (
+	Up    Direction = "up"
+	Down  Direction = "down"
+	Left  Direction = "left"
+	Right Direction = "right"
+)
+
+// Directions returns every supported traversal direction.
+//
+// Returns:
+//   - []Direction
+func Directions() []Direction {
+	return []Direction{Up, Down, Left, Right}
+}
+
+// ParseDirection returns the Direction named s.
+//
+// Parameters:
+//   - s: The name of the direction (case-sensitive)
+//
+// Returns:
+//   - Direction
+//   - error: A *utils.ValidationError listing the supported directions when s is unknown
+func ParseDirection(s string) (Direction, error) {
+	direction := Direction(s)
+	if err := utils.ValidateOption("direction", direction, Directions()); err != nil {
+		return "", err
+	}
+	return direction, nil
+}
+
 // PointillismLuminanceGridBased transforms an image into a pointillism-style artwork
 // using a grid-based approach where points are sized according to luminance values.
 // The algorithm creates a visual effect similar to pointillist paintings, where
@@ -25,38 +60,63 @@ import (
 //
 // Returns:
 //   - image.Image
+//
+// An unknown direction draws nothing, use PointillismLuminanceGridBasedStrict to reject it.
 func PointillismLuminanceGridBased(img image.Image, scalling int, direction string) image.Image {
+	return PointillismLuminanceGridBasedWithSource(img, scalling, direction, nil)
+}
+
+// PointillismLuminanceGridBasedWithSource works like PointillismLuminanceGridBased
+// but picks the radius of the darkest points with src, so the same source state
+// always produces the same image.
+//
+// Parameters:
+//   - img: The input image to be transformed
+//   - scalling: Controls the maximum radius of the points (1-100)
+//   - direction: The traversal direction: "up", "down", "left" or "right"
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image
+func PointillismLuminanceGridBasedWithSource(img image.Image, scalling int, direction string, src rand.Source) image.Image {
 
+	random := utils.NewRand(src)
 	scalling = utils.ClampGeneric(scalling, 1, 100)
 	box := 3
 	edge := box / 2
 
 	bounds := img.Bounds()
 	newImage := image.NewRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
 	directionFunc := func(x, y int) {
 
-		var grid []color.Color
+		var sumR, sumG, sumB, sumA, count uint32
 
 		for dy := 0; dy < box; dy++ {
 			for dx := 0; dx < box; dx++ {
 				px := x + dx - edge
 				py := y + dy - edge
 
-				if px >= 0 && px < bounds.Max.X && py >= 0 && py < bounds.Max.Y {
-					grid = append(grid, img.At(px, py))
+				if px >= bounds.Min.X && px < bounds.Max.X && py >= bounds.Min.Y && py < bounds.Max.Y {
+					r, g, b, a := reader.RGBA(px, py)
+					sumR += r
+					sumG += g
+					sumB += b
+					sumA += a
+					count++
 				}
 			}
 		}
 
-		averageColor := utils.ColorAverage(grid)
-		r, g, b, _ := averageColor.RGBA()
-		luminance := utils.Luminance16bit(r, g, b) * float64(scalling)
+		averageR, averageG, averageB := utils.Unpremultiply(sumR/count, sumG/count, sumB/count, sumA/count)
+		averageColor := color.NRGBA64{uint16(averageR), uint16(averageG), uint16(averageB), uint16(sumA / count)}
+		luminance := utils.Luminance16bit(averageR, averageG, averageB) * float64(scalling)
 		radius := int(luminance / (3 * 65535) * 5)
 		radius = utils.ClampGeneric(int(radius), 1, scalling)
 
 		if radius == 1 {
-			radius = rand.Intn(scalling / 2)
+			radius = random.IntN(max(scalling/2, 1))
 		}
 
 		radius_calc := radius * radius
@@ -70,31 +130,31 @@ func PointillismLuminanceGridBased(img image.Image, scalling int, direction stri
 		}
 	}
 
-	switch direction {
-	case "up":
-		for y := 0; y < bounds.Max.Y; y += edge {
-			for x := 0; x < bounds.Max.X; x += edge {
+	switch Direction(direction) {
+	case Up:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += edge {
+			for x := bounds.Min.X; x < bounds.Max.X; x += edge {
 				directionFunc(x, y)
 			}
 		}
 
-	case "down":
-		for y := bounds.Max.Y; y > 0; y -= edge {
-			for x := bounds.Max.X; x > 0; x -= edge {
+	case Down:
+		for y := bounds.Max.Y; y > bounds.Min.Y; y -= edge {
+			for x := bounds.Max.X; x > bounds.Min.X; x -= edge {
 				directionFunc(x, y)
 			}
 		}
 
-	case "left":
-		for x := 0; x < bounds.Max.X; x += edge {
-			for y := bounds.Max.Y; y > 0; y -= edge {
+	case Left:
+		for x := bounds.Min.X; x < bounds.Max.X; x += edge {
+			for y := bounds.Max.Y; y > bounds.Min.Y; y -= edge {
 				directionFunc(x, y)
 			}
 		}
 
-	case "right":
-		for x := bounds.Max.X; x > 0; x -= edge {
-			for y := bounds.Max.Y; y > 0; y -= edge {
+	case Right:
+		for x := bounds.Max.X; x > bounds.Min.X; x -= edge {
+			for y := bounds.Max.Y; y > bounds.Min.Y; y -= edge {
 				directionFunc(x, y)
 			}
 		}
@@ -102,3 +162,41 @@ func PointillismLuminanceGridBased(img image.Image, scalling int, direction stri
 
 	return newImage
 }
+
+// PointillismLuminanceGridBasedStrict works like PointillismLuminanceGridBased but
+// returns an error instead of clamping the scaling or drawing nothing for an
+// unknown direction.
+//
+// Parameters:
+//   - img: The input image to be transformed
+//   - scalling: The maximum radius of the points (1-100)
+//   - direction: The traversal direction, one of Directions()
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError describing the first invalid parameter
+func PointillismLuminanceGridBasedStrict(img image.Image, scalling int, direction Direction) (image.Image, error) {
+	return PointillismLuminanceGridBasedStrictWithSource(img, scalling, direction, nil)
+}
+
+// PointillismLuminanceGridBasedStrictWithSource combines
+// PointillismLuminanceGridBasedStrict and PointillismLuminanceGridBasedWithSource.
+//
+// Parameters:
+//   - img: The input image to be transformed
+//   - scalling: The maximum radius of the points (1-100)
+//   - direction: The traversal direction, one of Directions()
+//   - src: The random source, nil uses a randomly seeded one
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError describing the first invalid parameter
+func PointillismLuminanceGridBasedStrictWithSource(img image.Image, scalling int, direction Direction, src rand.Source) (image.Image, error) {
+	if err := utils.ValidateRange("scalling", scalling, 1, 100); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateOption("direction", direction, Directions()); err != nil {
+		return nil, err
+	}
+	return PointillismLuminanceGridBasedWithSource(img, scalling, string(direction), src), nil
+}
@@ -0,0 +1,58 @@
+package resize
+
+import (
+	"image"
+	"math"
+
+	"github.com/BrunoPoiano/imgeffects/linear"
+)
+
+// BypolarInterpolateLinear works like BypolarInterpolate but interpolates the
+// colours in linear light, and keeps the alpha channel. Downscaled fine
+// detail keeps its brightness instead of turning darker.
+// NearestNeighbor copies pixels without mixing them and needs no linear variant.
+//
+// Parameters:
+//   - img: The input image to be resized, converted with linear.FromImage
+//   - newWidth: The desired width of the resized image (in pixels)
+//   - newHeight: The desired height of the resized image (in pixels)
+//
+// Returns:
+//   - image.Image: A *linear.Image
+func BypolarInterpolateLinear(img image.Image, newWidth, newHeight int) image.Image {
+	src := linear.FromImage(img)
+	bounds := src.Bounds()
+	oldWidth, oldHeight := bounds.Dx(), bounds.Dy()
+	newImage := linear.NewImage(image.Rect(0, 0, newWidth, newHeight))
+
+	ratioX := float64(oldWidth) / float64(newWidth)
+	ratioY := float64(oldHeight) / float64(newHeight)
+
+	for y := 0; y < newHeight; y++ {
+		srcY := float64(y) * ratioY
+		y0 := int(math.Floor(srcY))
+		y1 := min(y0+1, oldHeight-1)
+		dy := float32(srcY - float64(y0))
+
+		for x := 0; x < newWidth; x++ {
+			srcX := float64(x) * ratioX
+			x0 := int(math.Floor(srcX))
+			x1 := min(x0+1, oldWidth-1)
+			dx := float32(srcX - float64(x0))
+
+			c00 := src.LinearAt(bounds.Min.X+x0, bounds.Min.Y+y0)
+			c10 := src.LinearAt(bounds.Min.X+x1, bounds.Min.Y+y0)
+			c01 := src.LinearAt(bounds.Min.X+x0, bounds.Min.Y+y1)
+			c11 := src.LinearAt(bounds.Min.X+x1, bounds.Min.Y+y1)
+
+			w00, w10, w01, w11 := (1-dx)*(1-dy), dx*(1-dy), (1-dx)*dy, dx*dy
+			newImage.SetLinear(x, y, linear.Color{
+				R: w00*c00.R + w10*c10.R + w01*c01.R + w11*c11.R,
+				G: w00*c00.G + w10*c10.G + w01*c01.G + w11*c11.G,
+				B: w00*c00.B + w10*c10.B + w01*c01.B + w11*c11.B,
+				A: w00*c00.A + w10*c10.A + w01*c01.A + w11*c11.A,
+			})
+		}
+	}
+	return newImage
+}
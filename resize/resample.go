@@ -0,0 +1,417 @@
+package resize
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/linear"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// Filter is a resampling kernel of Resample.
+type Filter string
+
+const (
+	Box        Filter = "box"
+	Bilinear   Filter = "bilinear"
+	CatmullRom Filter = "catmull-rom"
+	Mitchell   Filter = "mitchell"
+	Lanczos3   Filter = "lanczos3"
+)
+
+// Filters returns every supported resampling filter.
+//
+// Returns:
+//   - []Filter
+func Filters() []Filter {
+	return []Filter{Box, Bilinear, CatmullRom, Mitchell, Lanczos3}
+}
+
+// ParseFilter returns the Filter named s.
+//
+// Parameters:
+//   - s: The name of the filter (case-sensitive)
+//
+// Returns:
+//   - Filter
+//   - error: A *utils.ValidationError listing the supported filters when s is unknown
+func ParseFilter(s string) (Filter, error) {
+	filter := Filter(s)
+	if err := utils.ValidateOption("filter", filter, Filters()); err != nil {
+		return "", err
+	}
+	return filter, nil
+}
+
+// kernel is a resampling filter, at returns its weight for a distance in
+// source pixels and is 0 beyond support.
+type kernel struct {
+	support float64
+	at      func(x float64) float64
+}
+
+var kernels = map[Filter]kernel{
+	Box: {0.5, func(x float64) float64 {
+		if x >= -0.5 && x < 0.5 {
+			return 1
+		}
+		return 0
+	}},
+	Bilinear: {1, func(x float64) float64 {
+		return max(1-math.Abs(x), 0)
+	}},
+	CatmullRom: {2, func(x float64) float64 { return cubic(x, 0, 0.5) }},
+	Mitchell:   {2, func(x float64) float64 { return cubic(x, 1.0/3, 1.0/3) }},
+	Lanczos3: {3, func(x float64) float64 {
+		if x <= -3 || x >= 3 {
+			return 0
+		}
+		return sinc(x) * sinc(x/3)
+	}},
+}
+
+// cubic is the Mitchell-Netravali family of cubic filters with parameters b and c.
+func cubic(x, b, c float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+	case x < 2:
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	}
+	return 0
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// contribution lists the source pixels mixed into one destination pixel.
+type contribution struct {
+	index  []int
+	weight []float32
+}
+
+// contributions computes the weights of a resize from srcSize to dstSize
+// pixels along one axis. When downscaling the kernel is stretched to cover
+// every source pixel, which averages the detail instead of aliasing it.
+// Samples beyond the edges repeat the edge pixel.
+func contributions(srcSize, dstSize int, k kernel) []contribution {
+	scale := float64(srcSize) / float64(dstSize)
+	filterScale := max(scale, 1)
+	radius := k.support * filterScale
+
+	result := make([]contribution, dstSize)
+	weights := make([]float64, 0, int(2*radius)+2)
+	for i := range result {
+		center := (float64(i)+0.5)*scale - 0.5
+		left := int(math.Ceil(center - radius))
+		right := int(math.Floor(center + radius))
+
+		var c contribution
+		var sum float64
+		weights = weights[:0]
+		for j := left; j <= right; j++ {
+			w := k.at((float64(j) - center) / filterScale)
+			if w == 0 {
+				continue
+			}
+			c.index = append(c.index, utils.ClampGeneric(j, 0, srcSize-1))
+			weights = append(weights, w)
+			sum += w
+		}
+
+		c.weight = make([]float32, len(weights))
+		for n, w := range weights {
+			c.weight[n] = float32(w / sum)
+		}
+		result[i] = c
+	}
+	return result
+}
+
+// plane is a buffer of premultiplied RGBA float32 values, 4 per pixel.
+type plane struct {
+	pix           []float32
+	stride        int
+	width, height int
+}
+
+func newPlane(width, height int) plane {
+	return plane{pix: make([]float32, 4*width*height), stride: 4 * width, width: width, height: height}
+}
+
+// resamplePass resizes src into dst along one axis, in parallel over the rows of dst.
+func resamplePass(ctx context.Context, img image.Image, src, dst plane, contribs []contribution, horizontal bool, progress utils.ProgressFunc) error {
+	passFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			o := y * dst.stride
+			for x := 0; x < dst.width; x++ {
+				var r, g, b, a float32
+
+				var c contribution
+				if horizontal {
+					c = contribs[x]
+				} else {
+					c = contribs[y]
+				}
+				for n, index := range c.index {
+					i := y*src.stride + index*4
+					if !horizontal {
+						i = index*src.stride + x*4
+					}
+					s := src.pix[i : i+4 : i+4]
+					w := c.weight[n]
+					r += s[0] * w
+					g += s[1] * w
+					b += s[2] * w
+					a += s[3] * w
+				}
+
+				d := dst.pix[o : o+4 : o+4]
+				d[0], d[1], d[2], d[3] = r, g, b, a
+				o += 4
+			}
+		}
+	}
+
+	_, err := utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{
+		Image:    img,
+		Function: passFunc,
+		EndSize:  dst.height,
+		Progress: progress,
+		NoOutput: true,
+	})
+	return err
+}
+
+// resample runs the horizontal and the vertical pass of filter over src.
+// Unknown filters fall back to Bilinear.
+func resample(ctx context.Context, img image.Image, src plane, newWidth, newHeight int, filter Filter, progress utils.ProgressFunc) (plane, error) {
+	k, ok := kernels[filter]
+	if !ok {
+		k = kernels[Bilinear]
+	}
+
+	var horizontalProgress, verticalProgress utils.ProgressFunc
+	if progress != nil {
+		total := src.height + newHeight
+		horizontalProgress = func(done, _ int) { progress(done, total) }
+		verticalProgress = func(done, _ int) { progress(src.height+done, total) }
+	}
+
+	horizontal := newPlane(newWidth, src.height)
+	err := resamplePass(ctx, img, src, horizontal, contributions(src.width, newWidth, k), true, horizontalProgress)
+	if err != nil {
+		return plane{}, err
+	}
+
+	result := newPlane(newWidth, newHeight)
+	err = resamplePass(ctx, img, horizontal, result, contributions(src.height, newHeight, k), false, verticalProgress)
+	if err != nil {
+		return plane{}, err
+	}
+	return result, nil
+}
+
+// Resample resizes an image with a separable resampling filter. When
+// downscaling, the filter is widened to the size of a destination pixel, so
+// every source pixel contributes and fine detail is averaged instead of
+// aliased. The alpha channel is resampled with the colours.
+//
+// Supported filters:
+//   - box: Averages the covered area, fast and soft, the best choice for large reductions
+//   - bilinear: Tent filter, smooth with little ringing
+//   - catmull-rom: Sharp bicubic, interpolates the source pixels exactly
+//   - mitchell: Bicubic balancing blur and ringing
+//   - lanczos3: Sharpest result, with slight ringing around hard edges
+//
+// Parameters:
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (in pixels)
+//   - newHeight: The desired height of the resized image (in pixels)
+//   - filter: The resampling filter, one of Filters()
+//
+// Returns:
+//   - image.Image
+//
+// An unknown filter is treated like Bilinear, use ResampleStrict to reject it.
+func Resample(img image.Image, newWidth, newHeight int, filter Filter) image.Image {
+	newImage, _ := ResampleContext(context.Background(), img, newWidth, newHeight, filter, nil)
+	return newImage
+}
+
+// ResampleContext works like Resample but aborts as soon as ctx is cancelled
+// and reports the number of completed rows of both passes to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (in pixels)
+//   - newHeight: The desired height of the resized image (in pixels)
+//   - filter: The resampling filter, one of Filters()
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The resized image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func ResampleContext(ctx context.Context, img image.Image, newWidth, newHeight int, filter Filter, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	newImage := image.NewRGBA64(image.Rect(0, 0, max(newWidth, 0), max(newHeight, 0)))
+	if bounds.Empty() || newImage.Rect.Empty() {
+		return newImage, nil
+	}
+
+	src := newPlane(bounds.Dx(), bounds.Dy())
+	reader := pixel.NewReader(img)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		i := (y - bounds.Min.Y) * src.stride
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			s := src.pix[i : i+4 : i+4]
+			s[0], s[1], s[2], s[3] = float32(r)/0xffff, float32(g)/0xffff, float32(b)/0xffff, float32(a)/0xffff
+			i += 4
+		}
+	}
+
+	result, err := resample(ctx, img, src, newWidth, newHeight, filter, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	for y := 0; y < newHeight; y++ {
+		i := y * result.stride
+		for x := 0; x < newWidth; x++ {
+			s := result.pix[i : i+4 : i+4]
+			// The negative lobes of the sharper filters overshoot, the channels
+			// are premultiplied and must stay between 0 and their alpha.
+			a := min(max(s[3], 0), 1)
+			newImage.SetRGBA64(x, y, toRGBA64(s[0], s[1], s[2], a))
+			i += 4
+		}
+	}
+	return newImage, nil
+}
+
+// ResampleStrict works like Resample but returns an error instead of
+// ignoring an unknown filter or an empty size.
+//
+// Parameters:
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (1-65535)
+//   - newHeight: The desired height of the resized image (1-65535)
+//   - filter: The resampling filter, one of Filters()
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError describing the first invalid parameter
+func ResampleStrict(img image.Image, newWidth, newHeight int, filter Filter) (image.Image, error) {
+	return ResampleStrictContext(context.Background(), img, newWidth, newHeight, filter, nil)
+}
+
+// ResampleStrictContext works like ResampleStrict but aborts as soon as ctx is
+// cancelled and reports progress like ResampleContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (1-65535)
+//   - newHeight: The desired height of the resized image (1-65535)
+//   - filter: The resampling filter, one of Filters()
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The resized image, nil when cancelled or invalid
+//   - error: A *utils.ValidationError describing the first invalid parameter, or ctx.Err()
+//     when the context was cancelled
+func ResampleStrictContext(ctx context.Context, img image.Image, newWidth, newHeight int, filter Filter, progress utils.ProgressFunc) (image.Image, error) {
+	if err := utils.ValidateRange("width", newWidth, 1, 65535); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("height", newHeight, 1, 65535); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateOption("filter", filter, Filters()); err != nil {
+		return nil, err
+	}
+	return ResampleContext(ctx, img, newWidth, newHeight, filter, progress)
+}
+
+// ResampleLinear works like Resample but mixes the colours in linear light.
+//
+// Parameters:
+//   - img: The input image to be resized, converted with linear.FromImage
+//   - newWidth: The desired width of the resized image (in pixels)
+//   - newHeight: The desired height of the resized image (in pixels)
+//   - filter: The resampling filter, one of Filters()
+//
+// Returns:
+//   - image.Image: A *linear.Image
+func ResampleLinear(img image.Image, newWidth, newHeight int, filter Filter) image.Image {
+	newImage, _ := ResampleLinearContext(context.Background(), img, newWidth, newHeight, filter, nil)
+	return newImage
+}
+
+// ResampleLinearContext works like ResampleLinear but aborts as soon as ctx is
+// cancelled and reports progress like ResampleContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (in pixels)
+//   - newHeight: The desired height of the resized image (in pixels)
+//   - filter: The resampling filter, one of Filters()
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: A *linear.Image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func ResampleLinearContext(ctx context.Context, img image.Image, newWidth, newHeight int, filter Filter, progress utils.ProgressFunc) (image.Image, error) {
+	source := linear.FromImage(img)
+	bounds := source.Bounds()
+	newImage := linear.NewImage(image.Rect(0, 0, max(newWidth, 0), max(newHeight, 0)))
+	if bounds.Empty() || newImage.Rect.Empty() {
+		return newImage, nil
+	}
+
+	src := plane{
+		pix:    source.Pix[source.PixOffset(bounds.Min.X, bounds.Min.Y):],
+		stride: source.Stride,
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+	}
+	result, err := resample(ctx, img, src, newWidth, newHeight, filter, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(result.pix); i += 4 {
+		s := result.pix[i : i+4 : i+4]
+		a := min(max(s[3], 0), 1)
+		d := newImage.Pix[i : i+4 : i+4]
+		d[0], d[1], d[2], d[3] = min(max(s[0], 0), a), min(max(s[1], 0), a), min(max(s[2], 0), a), a
+	}
+	return newImage, nil
+}
+
+// toRGBA64 converts premultiplied float32 channels to 16-bit values, clamping
+// the colours between 0 and the alpha a.
+func toRGBA64(r, g, b, a float32) color.RGBA64 {
+	alpha := uint16(a*0xffff + 0.5)
+	channel := func(v float32) uint16 {
+		return min(uint16(min(max(v, 0), a)*0xffff+0.5), alpha)
+	}
+	return color.RGBA64{channel(r), channel(g), channel(b), alpha}
+}
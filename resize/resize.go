@@ -4,6 +4,8 @@ import (
 	"image"
 	"image/color"
 	"math"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 )
 
 // NewAspectRatio calculates the new dimensions for an image while preserving the aspect ratio.
@@ -25,6 +27,24 @@ func NewAspectRatio(img image.Image, newWidth int) (int, int) {
 	return newWidth, newHeight
 }
 
+// NewAspectRatioHeight works like NewAspectRatio for a given height, computing
+// the width that preserves the aspect ratio.
+//
+// Parameters:
+//   - img: The input image whose aspect ratio should be preserved
+//   - newHeight: The desired height of the resized image (in pixels)
+//
+// Returns:
+//   - int: The calculated new width that preserves aspect ratio
+//   - int: The requested new height (same as input newHeight)
+func NewAspectRatioHeight(img image.Image, newHeight int) (int, int) {
+	imgBounds := img.Bounds()
+	aspectRatio := float64(newHeight) / float64(imgBounds.Dy())
+	newWidth := int(float64(imgBounds.Dx()) * aspectRatio)
+
+	return newWidth, newHeight
+}
+
 // NearestNeighbor resizes an image using nearest neighbor interpolation.
 // This is the simplest and fastest resizing algorithm that works by selecting
 // the color of the nearest pixel in the original image for each pixel in the
@@ -41,13 +61,15 @@ func NewAspectRatio(img image.Image, newWidth int) (int, int) {
 func NearestNeighbor(img image.Image, newWidth, newHeight int) image.Image {
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(image.Rect(0, 0, newWidth, newHeight))
+	reader := pixel.NewReader(img)
+	writer := pixel.NewWriter(newImage)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		newY := y * bounds.Max.Y / newHeight
-		for x := 0; x < bounds.Max.X; x++ {
-			newX := x * bounds.Max.X / newWidth
-			color := img.At(newX, newY)
-			newImage.Set(x, y, color)
+	for y := 0; y < newHeight; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/newHeight
+		for x := 0; x < newWidth; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/newWidth
+			r, g, b, a := reader.RGBA(srcX, srcY)
+			writer.SetRGBA(x, y, r, g, b, a)
 		}
 	}
 
@@ -68,23 +90,30 @@ func NearestNeighbor(img image.Image, newWidth, newHeight int) image.Image {
 // Returns:
 //   - image.Image
 func BypolarInterpolate(img image.Image, newWidth, newHeight int) image.Image {
-	oldWidth, oldHeight := img.Bounds().Max.X, img.Bounds().Max.Y
+	bounds := img.Bounds()
+	oldWidth, oldHeight := bounds.Dx(), bounds.Dy()
 	newImage := image.NewNRGBA64(image.Rect(0, 0, newWidth, newHeight))
+	reader := pixel.NewReader(img)
 
 	ratioX := float64(oldWidth) / float64(newWidth)
 	ratioY := float64(oldHeight) / float64(newHeight)
 
-	bilinearInterpolateColor := func(c00, c10, c01, c11 color.Color, dx, dy float64) color.RGBA64 {
-		r00, g00, b00, _ := c00.RGBA()
-		r10, g10, b10, _ := c10.RGBA()
-		r01, g01, b01, _ := c01.RGBA()
-		r11, g11, b11, _ := c11.RGBA()
+	bilinearInterpolateColor := func(x0, y0, x1, y1 int, dx, dy float64) color.RGBA64 {
+		x0, x1 = bounds.Min.X+x0, bounds.Min.X+x1
+		y0, y1 = bounds.Min.Y+y0, bounds.Min.Y+y1
+		r00, g00, b00, a00 := reader.RGBA(x0, y0)
+		r10, g10, b10, a10 := reader.RGBA(x1, y0)
+		r01, g01, b01, a01 := reader.RGBA(x0, y1)
+		r11, g11, b11, a11 := reader.RGBA(x1, y1)
 
 		r := (1-dx)*(1-dy)*float64(r00) + dx*(1-dy)*float64(r10) + (1-dx)*dy*float64(r01) + dx*dy*float64(r11)
 		g := (1-dx)*(1-dy)*float64(g00) + dx*(1-dy)*float64(g10) + (1-dx)*dy*float64(g01) + dx*dy*float64(g11)
 		b := (1-dx)*(1-dy)*float64(b00) + dx*(1-dy)*float64(b10) + (1-dx)*dy*float64(b01) + dx*dy*float64(b11)
+		a := (1-dx)*(1-dy)*float64(a00) + dx*(1-dy)*float64(a10) + (1-dx)*dy*float64(a01) + dx*dy*float64(a11)
 
-		return color.RGBA64{uint16(r), uint16(g), uint16(b), 65535.0}
+		// The channels are premultiplied, rounding must not push a colour above its alpha.
+		alpha := uint16(a)
+		return color.RGBA64{min(uint16(r), alpha), min(uint16(g), alpha), min(uint16(b), alpha), alpha}
 	}
 
 	for y := 0; y < newHeight; y++ {
@@ -99,12 +128,7 @@ func BypolarInterpolate(img image.Image, newWidth, newHeight int) image.Image {
 			x1 := int(math.Min(float64(x0+1), float64(oldWidth-1)))
 			dx := srcX - float64(x0)
 
-			c00 := img.At(x0, y0)
-			c10 := img.At(x1, y0)
-			c01 := img.At(x0, y1)
-			c11 := img.At(x1, y1)
-
-			newImage.SetRGBA64(x, y, bilinearInterpolateColor(c00, c10, c01, c11, dx, dy))
+			newImage.SetRGBA64(x, y, bilinearInterpolateColor(x0, y0, x1, y1, dx, dy))
 		}
 	}
 	return newImage
@@ -0,0 +1,83 @@
+package resize
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+)
+
+// Sampler reads an image at any position with the kernel of a Filter, for
+// the transforms mapping every destination pixel back to a source position
+// (perspective, lens distortion). Unlike Resample it does not widen the
+// kernel, so strong reductions alias.
+type Sampler struct {
+	reader pixel.Reader
+	bounds image.Rectangle
+	kernel kernel
+}
+
+// NewSampler returns a Sampler reading img with filter. Unknown filters fall
+// back to Bilinear, and Box picks the nearest pixel.
+//
+// Parameters:
+//   - img: The image to sample
+//   - filter: The resampling filter
+//
+// Returns:
+//   - *Sampler
+func NewSampler(img image.Image, filter Filter) *Sampler {
+	k, ok := kernels[filter]
+	if !ok {
+		k = kernels[Bilinear]
+	}
+	return &Sampler{reader: pixel.NewReader(img), bounds: img.Bounds(), kernel: k}
+}
+
+// At returns the premultiplied colour at (x, y), relative to the top left
+// corner of the image, where the pixel at the offset (i, j) covers
+// [i, i+1) x [j, j+1). The area outside the image is transparent, which
+// blends the edges of a warped image into its background.
+//
+// Parameters:
+//   - x, y: The position to sample, relative to the top left corner
+//
+// Returns:
+//   - color.RGBA64
+func (s *Sampler) At(x, y float64) color.RGBA64 {
+	x, y = x-0.5, y-0.5
+	support := s.kernel.support
+
+	var r, g, b, a, sum float64
+	for j := int(math.Ceil(y - support)); j <= int(math.Floor(y+support)); j++ {
+		wy := s.kernel.at(y - float64(j))
+		if wy == 0 {
+			continue
+		}
+		for i := int(math.Ceil(x - support)); i <= int(math.Floor(x+support)); i++ {
+			w := wy * s.kernel.at(x-float64(i))
+			if w == 0 {
+				continue
+			}
+			sum += w
+			if i < 0 || j < 0 || i >= s.bounds.Dx() || j >= s.bounds.Dy() {
+				continue
+			}
+			pr, pg, pb, pa := s.reader.RGBA(s.bounds.Min.X+i, s.bounds.Min.Y+j)
+			r += float64(pr) * w
+			g += float64(pg) * w
+			b += float64(pb) * w
+			a += float64(pa) * w
+		}
+	}
+	if sum == 0 {
+		return color.RGBA64{}
+	}
+
+	// The negative lobes of the sharper filters overshoot, the channels are
+	// premultiplied and must stay between 0 and their alpha.
+	a = min(max(a/sum, 0), 0xffff)
+	channel := func(v float64) uint16 { return uint16(min(max(v/sum, 0), a) + 0.5) }
+	return color.RGBA64{channel(r), channel(g), channel(b), uint16(a + 0.5)}
+}
@@ -0,0 +1,462 @@
+package resize
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// Mask values of the carver pixels.
+const (
+	protected int8 = 1
+	removed   int8 = -1
+)
+
+// maskEnergy is added to the energy of protected pixels and subtracted from
+// the removed ones. It is larger than the energy of any seam of unmasked
+// pixels, so seams go around protected pixels and through removed ones.
+const maskEnergy = 1e7
+
+// carver holds the image being carved. Seams are always vertical, horizontal
+// seams are carved on the transposed image.
+type carver struct {
+	width, height int
+	pix           []color.RGBA64
+	mask          []int8
+	// origin holds the column of each pixel before the seams were removed,
+	// used to find where to insert seams when enlarging.
+	origin []int
+	// luminance and energy are kept up to date as seams are removed, nil
+	// when they must be computed again.
+	luminance, energy []float64
+	cost              []float64
+	// removedLeft is the number of pixels marked for removal.
+	removedLeft int
+}
+
+func newCarver(img, protect, remove image.Image) *carver {
+	bounds := img.Bounds()
+	c := &carver{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		pix:    make([]color.RGBA64, bounds.Dx()*bounds.Dy()),
+		mask:   make([]int8, bounds.Dx()*bounds.Dy()),
+	}
+
+	reader := pixel.NewReader(img)
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			r, g, b, a := reader.RGBA(bounds.Min.X+x, bounds.Min.Y+y)
+			c.pix[y*c.width+x] = color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+		}
+	}
+
+	// The masks are aligned on the top left corner of the image. A mask pixel
+	// is selected when it is brighter than half, counting transparent as black.
+	for _, m := range []struct {
+		img   image.Image
+		value int8
+	}{{protect, protected}, {remove, removed}} {
+		if m.img == nil {
+			continue
+		}
+		maskBounds := m.img.Bounds()
+		maskReader := pixel.NewReader(m.img)
+		for y := 0; y < min(c.height, maskBounds.Dy()); y++ {
+			for x := 0; x < min(c.width, maskBounds.Dx()); x++ {
+				r, g, b, _ := maskReader.RGBA(maskBounds.Min.X+x, maskBounds.Min.Y+y)
+				i := y*c.width + x
+				if utils.Luminance16bit(r, g, b) >= 0x8000 && c.mask[i] != m.value {
+					if m.value == removed {
+						c.removedLeft++
+					}
+					c.mask[i] = m.value
+				}
+			}
+		}
+	}
+	return c
+}
+
+// transpose swaps the rows and the columns of the image.
+func (c *carver) transpose() {
+	pix := make([]color.RGBA64, len(c.pix))
+	mask := make([]int8, len(c.mask))
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			pix[x*c.height+y] = c.pix[y*c.width+x]
+			mask[x*c.height+y] = c.mask[y*c.width+x]
+		}
+	}
+	c.pix, c.mask = pix, mask
+	c.width, c.height = c.height, c.width
+	c.luminance, c.energy = nil, nil
+}
+
+// computeEnergy computes the luminance and the energy of every pixel.
+func (c *carver) computeEnergy() {
+	c.luminance = make([]float64, len(c.pix))
+	for i, p := range c.pix {
+		c.luminance[i] = (0.299*float64(p.R) + 0.587*float64(p.G) + 0.114*float64(p.B)) / 0xffff
+	}
+	c.energy = make([]float64, len(c.pix))
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			c.energy[y*c.width+x] = c.energyAt(x, y)
+		}
+	}
+}
+
+// energyAt returns the Sobel gradient magnitude of the luminance at (x, y),
+// using the kernels of edgedetection.KernelOperatorBased, with the masks applied.
+func (c *carver) energyAt(x, y int) float64 {
+	at := func(x, y int) float64 {
+		x = utils.ClampGeneric(x, 0, c.width-1)
+		y = utils.ClampGeneric(y, 0, c.height-1)
+		return c.luminance[y*c.width+x]
+	}
+	gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+	gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+	return math.Sqrt(gx*gx+gy*gy) + float64(c.mask[y*c.width+x])*maskEnergy
+}
+
+// findSeam returns the column of every row of the connected vertical seam
+// with the lowest total energy.
+func (c *carver) findSeam() []int {
+	if c.energy == nil {
+		c.computeEnergy()
+	}
+	if cap(c.cost) < len(c.energy) {
+		c.cost = make([]float64, len(c.energy))
+	}
+	cost := c.cost[:len(c.energy)]
+	copy(cost, c.energy)
+	for y := 1; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			above := cost[(y-1)*c.width+x]
+			if x > 0 {
+				above = min(above, cost[(y-1)*c.width+x-1])
+			}
+			if x < c.width-1 {
+				above = min(above, cost[(y-1)*c.width+x+1])
+			}
+			cost[y*c.width+x] += above
+		}
+	}
+
+	seam := make([]int, c.height)
+	last := (c.height - 1) * c.width
+	for x := 1; x < c.width; x++ {
+		if cost[last+x] < cost[last+seam[c.height-1]] {
+			seam[c.height-1] = x
+		}
+	}
+	for y := c.height - 2; y >= 0; y-- {
+		best := seam[y+1]
+		for x := max(best-1, 0); x <= min(best+1, c.width-1); x++ {
+			if cost[y*c.width+x] < cost[y*c.width+best] {
+				best = x
+			}
+		}
+		seam[y] = best
+	}
+	return seam
+}
+
+// removeRow removes the element s of every row of width elements of v, in
+// place, and returns the shortened slice.
+func removeRow[T any](v []T, width int, seam []int) []T {
+	if v == nil {
+		return nil
+	}
+	n := 0
+	for y, s := range seam {
+		row := y * width
+		n += copy(v[n:], v[row:row+s])
+		n += copy(v[n:], v[row+s+1:row+width])
+	}
+	return v[:n]
+}
+
+// removeSeam removes the pixel of the seam from every row. Only the energy
+// of the pixels whose Sobel window held a pixel of the seam is computed
+// again.
+func (c *carver) removeSeam(seam []int) {
+	for y, s := range seam {
+		if c.mask[y*c.width+s] == removed {
+			c.removedLeft--
+		}
+	}
+
+	width := c.width
+	c.pix = removeRow(c.pix, width, seam)
+	c.mask = removeRow(c.mask, width, seam)
+	c.origin = removeRow(c.origin, width, seam)
+	c.luminance = removeRow(c.luminance, width, seam)
+	c.energy = removeRow(c.energy, width, seam)
+	c.width--
+	if c.energy == nil {
+		return
+	}
+
+	// The pixels of a row next to the seam, in that row or in the rows
+	// above and below, lost or gained a neighbour.
+	for y := range c.height {
+		lo, hi := seam[y], seam[y]
+		for _, dy := range []int{-1, 1} {
+			if y+dy >= 0 && y+dy < c.height {
+				lo, hi = min(lo, seam[y+dy]), max(hi, seam[y+dy])
+			}
+		}
+		for x := max(lo-2, 0); x <= min(hi+1, c.width-1); x++ {
+			c.energy[y*c.width+x] = c.energyAt(x, y)
+		}
+	}
+}
+
+// insertSeams enlarges the image by count columns, duplicating the count
+// seams that would be removed first. Every inserted pixel is the average of
+// the duplicated pixel and its right neighbour.
+func (c *carver) insertSeams(count int) {
+	search := &carver{
+		width:  c.width,
+		height: c.height,
+		pix:    slices.Clone(c.pix),
+		mask:   slices.Clone(c.mask),
+		origin: make([]int, len(c.pix)),
+	}
+	for i := range search.origin {
+		search.origin[i] = i % c.width
+	}
+
+	duplicate := make([]bool, len(c.pix))
+	for range count {
+		seam := search.findSeam()
+		for y, x := range seam {
+			duplicate[y*c.width+search.origin[y*search.width+x]] = true
+		}
+		search.removeSeam(seam)
+	}
+
+	width := c.width + count
+	pix := make([]color.RGBA64, 0, width*c.height)
+	mask := make([]int8, 0, width*c.height)
+	for y := 0; y < c.height; y++ {
+		row := y * c.width
+		for x := 0; x < c.width; x++ {
+			p := c.pix[row+x]
+			pix = append(pix, p)
+			mask = append(mask, c.mask[row+x])
+			if duplicate[row+x] {
+				q := c.pix[row+min(x+1, c.width-1)]
+				pix = append(pix, color.RGBA64{
+					uint16((uint32(p.R) + uint32(q.R)) / 2),
+					uint16((uint32(p.G) + uint32(q.G)) / 2),
+					uint16((uint32(p.B) + uint32(q.B)) / 2),
+					uint16((uint32(p.A) + uint32(q.A)) / 2),
+				})
+				mask = append(mask, c.mask[row+x])
+				if c.mask[row+x] == removed {
+					c.removedLeft++
+				}
+			}
+		}
+	}
+	c.pix, c.mask = pix, mask
+	c.width = width
+	c.luminance, c.energy = nil, nil
+}
+
+// removedIsWide reports whether the pixels marked for removal span more
+// columns than rows.
+func (c *carver) removedIsWide() bool {
+	area := image.Rectangle{}
+	for i, m := range c.mask {
+		if m == removed {
+			p := image.Rect(i%c.width, i/c.width, i%c.width+1, i/c.width+1)
+			area = area.Union(p)
+		}
+	}
+	return area.Dx() > area.Dy()
+}
+
+// resizeWidth removes or inserts vertical seams until the image is width
+// pixels wide. Enlarging inserts at most half of the current width at a time,
+// so the same low energy seams are not stretched over and over.
+func (c *carver) resizeWidth(ctx context.Context, width int, step func(int)) error {
+	for c.width > width {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.removeSeam(c.findSeam())
+		step(1)
+	}
+	for c.width < width {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		count := min(width-c.width, max(c.width/2, 1))
+		c.insertSeams(count)
+		step(count)
+	}
+	return nil
+}
+
+// SeamCarving resizes an image by removing or inserting the seams of lowest
+// energy, paths of pixels crossing the image, instead of scaling it. Areas
+// with details keep their shape and size while flat areas, like the sky or a
+// wall, absorb the change of aspect ratio. The energy is the Sobel gradient
+// magnitude of the luminance. The width is carved first, then the height.
+//
+// Parameters:
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (in pixels)
+//   - newHeight: The desired height of the resized image (in pixels)
+//
+// Returns:
+//   - image.Image
+func SeamCarving(img image.Image, newWidth, newHeight int) image.Image {
+	newImage, _ := SeamCarvingContext(context.Background(), img, newWidth, newHeight, nil, nil, nil)
+	return newImage
+}
+
+// SeamCarvingMask works like SeamCarving but takes masks of pixels to protect
+// and to remove, aligned on the top left corner of the image. White mask
+// pixels are selected and black or transparent ones are not. Seams go around
+// protected pixels, and are carved through the removed pixels until none is
+// left before the image is brought to the requested size, which removes an
+// object without cropping the rest of the image.
+//
+// Parameters:
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (in pixels)
+//   - newHeight: The desired height of the resized image (in pixels)
+//   - protect: The mask of the pixels to keep, may be nil
+//   - remove: The mask of the pixels to remove, may be nil
+//
+// Returns:
+//   - image.Image
+func SeamCarvingMask(img image.Image, newWidth, newHeight int, protect, remove image.Image) image.Image {
+	newImage, _ := SeamCarvingContext(context.Background(), img, newWidth, newHeight, protect, remove, nil)
+	return newImage
+}
+
+// SeamCarvingContext works like SeamCarvingMask but aborts as soon as ctx is
+// cancelled and reports the number of carved and inserted seams to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (in pixels)
+//   - newHeight: The desired height of the resized image (in pixels)
+//   - protect: The mask of the pixels to keep, may be nil
+//   - remove: The mask of the pixels to remove, may be nil
+//   - progress: Optional callback receiving the completed and total seams, may be nil
+//
+// Returns:
+//   - image.Image: The resized image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func SeamCarvingContext(ctx context.Context, img image.Image, newWidth, newHeight int, protect, remove image.Image, progress utils.ProgressFunc) (image.Image, error) {
+	newWidth, newHeight = max(newWidth, 1), max(newHeight, 1)
+	if img.Bounds().Empty() {
+		return image.NewRGBA64(image.Rect(0, 0, newWidth, newHeight)), nil
+	}
+
+	c := newCarver(img, protect, remove)
+
+	// Carve the removed pixels along their shortest side.
+	wide := c.removedIsWide()
+	if wide {
+		c.transpose()
+	}
+	for c.removedLeft > 0 && c.width > 1 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		c.removeSeam(c.findSeam())
+	}
+	if wide {
+		c.transpose()
+	}
+
+	done := 0
+	total := abs(c.width-newWidth) + abs(c.height-newHeight)
+	step := func(seams int) {
+		done += seams
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+
+	if err := c.resizeWidth(ctx, newWidth, step); err != nil {
+		return nil, err
+	}
+	c.transpose()
+	if err := c.resizeWidth(ctx, newHeight, step); err != nil {
+		return nil, err
+	}
+	c.transpose()
+
+	newImage := image.NewRGBA64(image.Rect(0, 0, c.width, c.height))
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			newImage.SetRGBA64(x, y, c.pix[y*c.width+x])
+		}
+	}
+	return newImage, nil
+}
+
+// SeamCarvingStrict works like SeamCarvingMask but returns an error for a size
+// out of range instead of clamping it.
+//
+// Parameters:
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (1-65535)
+//   - newHeight: The desired height of the resized image (1-65535)
+//   - protect: The mask of the pixels to keep, may be nil
+//   - remove: The mask of the pixels to remove, may be nil
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError describing the first invalid parameter
+func SeamCarvingStrict(img image.Image, newWidth, newHeight int, protect, remove image.Image) (image.Image, error) {
+	return SeamCarvingStrictContext(context.Background(), img, newWidth, newHeight, protect, remove, nil)
+}
+
+// SeamCarvingStrictContext works like SeamCarvingStrict but aborts as soon as
+// ctx is cancelled and reports progress like SeamCarvingContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image to be resized
+//   - newWidth: The desired width of the resized image (1-65535)
+//   - newHeight: The desired height of the resized image (1-65535)
+//   - protect: The mask of the pixels to keep, may be nil
+//   - remove: The mask of the pixels to remove, may be nil
+//   - progress: Optional callback receiving the completed and total seams, may be nil
+//
+// Returns:
+//   - image.Image: The resized image, nil when cancelled or invalid
+//   - error: A *utils.ValidationError describing the first invalid parameter, or ctx.Err()
+//     when the context was cancelled
+func SeamCarvingStrictContext(ctx context.Context, img image.Image, newWidth, newHeight int, protect, remove image.Image, progress utils.ProgressFunc) (image.Image, error) {
+	if err := utils.ValidateRange("width", newWidth, 1, 65535); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("height", newHeight, 1, 65535); err != nil {
+		return nil, err
+	}
+	return SeamCarvingContext(ctx, img, newWidth, newHeight, protect, remove, progress)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
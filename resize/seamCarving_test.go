@@ -0,0 +1,183 @@
+package resize
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// noise returns a gray image with a different level on every pixel, so that
+// no seam is cheaper than the flat areas drawn on top of it.
+func noise(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	seed := uint32(1)
+	for i := 0; i < len(img.Pix); i += 4 {
+		seed = seed*1664525 + 1013904223
+		v := uint8(seed >> 24)
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = v, v, v, 255
+	}
+	return img
+}
+
+// fill paints r with c on img.
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
+
+// mask returns a width x height mask selecting r.
+func mask(width, height int, r image.Rectangle) image.Image {
+	m := image.NewGray(image.Rect(0, 0, width, height))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			m.SetGray(x, y, color.Gray{255})
+		}
+	}
+	return m
+}
+
+func carve(t *testing.T, img image.Image, width, height int, protect, remove image.Image) image.Image {
+	t.Helper()
+	out, err := SeamCarvingContext(context.Background(), img, width, height, protect, remove, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Bounds().Size(); got != image.Pt(width, height) {
+		t.Fatalf("got size %v, want %dx%d", got, width, height)
+	}
+	return out
+}
+
+// countColor returns the fewest pixels of color c found on a row of img.
+func countColor(img image.Image, c color.Color) int {
+	bounds := img.Bounds()
+	fewest := bounds.Dx()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		n := 0
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if sameColor(img.At(x, y), c) {
+				n++
+			}
+		}
+		fewest = min(fewest, n)
+	}
+	return fewest
+}
+
+func sameColor(a, b color.Color) bool {
+	r0, g0, b0, a0 := a.RGBA()
+	r1, g1, b1, a1 := b.RGBA()
+	return r0 == r1 && g0 == g1 && b0 == b1 && a0 == a1
+}
+
+func TestSeamCarvingProtect(t *testing.T) {
+	const width, height = 40, 20
+	band := color.RGBA{200, 0, 0, 255}
+	img := noise(width, height)
+	fill(img, image.Rect(10, 0, 20, height), band)
+
+	// The flat band holds the cheapest seams, so a plain shrink carves it.
+	if n := countColor(carve(t, img, width-8, height, nil, nil), band); n >= 6 {
+		t.Fatalf("a plain shrink kept %d columns of the band, the test image does not steer the seams", n)
+	}
+
+	protect := mask(width, height, image.Rect(12, 0, 18, height))
+	if n := countColor(carve(t, img, width-8, height, protect, nil), band); n < 6 {
+		t.Errorf("%d columns of the band are left on some row, want the 6 protected ones", n)
+	}
+}
+
+func TestSeamCarvingRemove(t *testing.T) {
+	const width, height = 40, 20
+	block := color.RGBA{0, 255, 0, 255}
+	img := noise(width, height)
+	fill(img, image.Rect(20, 5, 26, 15), block)
+	remove := mask(width, height, image.Rect(20, 5, 26, 15))
+
+	for _, size := range []image.Point{{width, height}, {30, 16}, {width + 5, height + 3}} {
+		checkRemoved(t, carve(t, img, size.X, size.Y, nil, remove))
+	}
+
+	// A wide block is removed with horizontal seams.
+	img = noise(width, height)
+	fill(img, image.Rect(5, 8, 35, 11), block)
+	checkRemoved(t, carve(t, img, width, height-3, nil, mask(width, height, image.Rect(5, 8, 35, 11))))
+}
+
+// checkRemoved fails when a green pixel, from the removed block or blended
+// with it, is left in img.
+func checkRemoved(t *testing.T, img image.Image) {
+	t.Helper()
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if r, g, _, _ := img.At(x, y).RGBA(); g > r+0x1000 {
+				t.Fatalf("%v: (%d, %d) is %v, a pixel of the removed block is left", bounds.Size(), x, y, img.At(x, y))
+			}
+		}
+	}
+}
+
+// TestSeamCarvingEnlarge checks that enlarging keeps every original row, or
+// column, in order.
+func TestSeamCarvingEnlarge(t *testing.T) {
+	const width, height = 20, 10
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			// The inserted averages never fall on a multiple of 10.
+			img.Set(x, y, color.RGBA{uint8(x * 10), uint8(y * 20), 128, 255})
+		}
+	}
+
+	for _, newWidth := range []int{27, 50} {
+		out := carve(t, img, newWidth, height, nil, nil)
+		for y := range height {
+			x := 0
+			for ox := range newWidth {
+				if x < width && sameColor(out.At(ox, y), img.At(x, y)) {
+					x++
+				}
+			}
+			if x != width {
+				t.Fatalf("width %d: row %d does not hold the original row in order", newWidth, y)
+			}
+		}
+	}
+
+	for _, newHeight := range []int{14, 25} {
+		out := carve(t, img, width, newHeight, nil, nil)
+		for x := range width {
+			y := 0
+			for oy := range newHeight {
+				if y < height && sameColor(out.At(x, oy), img.At(x, y)) {
+					y++
+				}
+			}
+			if y != height {
+				t.Fatalf("height %d: column %d does not hold the original column in order", newHeight, x)
+			}
+		}
+	}
+}
+
+// TestSeamCarvingEnergy checks that the energy updated around every removed
+// seam is the energy computed from scratch.
+func TestSeamCarvingEnergy(t *testing.T) {
+	img := noise(30, 12)
+	fill(img, image.Rect(8, 0, 14, 12), color.RGBA{90, 90, 90, 255})
+	c := newCarver(img, mask(30, 12, image.Rect(10, 2, 12, 6)), mask(30, 12, image.Rect(20, 4, 23, 9)))
+	for c.width > 1 {
+		c.removeSeam(c.findSeam())
+		got := slices.Clone(c.energy)
+		c.computeEnergy()
+		if !slices.Equal(got, c.energy) {
+			t.Fatalf("width %d: the updated energy differs from the computed one", c.width)
+		}
+	}
+}
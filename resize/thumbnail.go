@@ -0,0 +1,334 @@
+package resize
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// Mode is the way Thumbnail fits an image into the requested size.
+type Mode string
+
+const (
+	Fit     Mode = "fit"
+	Fill    Mode = "fill"
+	Stretch Mode = "stretch"
+	Pad     Mode = "pad"
+)
+
+// Modes returns every supported thumbnail mode.
+//
+// Returns:
+//   - []Mode
+func Modes() []Mode {
+	return []Mode{Fit, Fill, Stretch, Pad}
+}
+
+// ParseMode returns the Mode named s.
+//
+// Parameters:
+//   - s: The name of the mode (case-sensitive)
+//
+// Returns:
+//   - Mode
+//   - error: A *utils.ValidationError listing the supported modes when s is unknown
+func ParseMode(s string) (Mode, error) {
+	mode := Mode(s)
+	if err := utils.ValidateOption("mode", mode, Modes()); err != nil {
+		return "", err
+	}
+	return mode, nil
+}
+
+// Anchor is the part of the image kept by the fill mode, and the side the
+// image is moved to by the pad mode.
+type Anchor string
+
+const (
+	Center      Anchor = "center"
+	Top         Anchor = "top"
+	Bottom      Anchor = "bottom"
+	Left        Anchor = "left"
+	Right       Anchor = "right"
+	TopLeft     Anchor = "top-left"
+	TopRight    Anchor = "top-right"
+	BottomLeft  Anchor = "bottom-left"
+	BottomRight Anchor = "bottom-right"
+)
+
+// Anchors returns every supported anchor.
+//
+// Returns:
+//   - []Anchor
+func Anchors() []Anchor {
+	return []Anchor{Center, Top, Bottom, Left, Right, TopLeft, TopRight, BottomLeft, BottomRight}
+}
+
+// ParseAnchor returns the Anchor named s.
+//
+// Parameters:
+//   - s: The name of the anchor (case-sensitive)
+//
+// Returns:
+//   - Anchor
+//   - error: A *utils.ValidationError listing the supported anchors when s is unknown
+func ParseAnchor(s string) (Anchor, error) {
+	anchor := Anchor(s)
+	if err := utils.ValidateOption("anchor", anchor, Anchors()); err != nil {
+		return "", err
+	}
+	return anchor, nil
+}
+
+// position returns the offset of a rectangle placed at the anchor inside a
+// larger one, free being the difference between their sizes. Unknown anchors
+// centre the rectangle.
+func (a Anchor) position(free image.Point) image.Point {
+	p := image.Point{free.X / 2, free.Y / 2}
+	switch a {
+	case Top, TopLeft, TopRight:
+		p.Y = 0
+	case Bottom, BottomLeft, BottomRight:
+		p.Y = free.Y
+	}
+	switch a {
+	case Left, TopLeft, BottomLeft:
+		p.X = 0
+	case Right, TopRight, BottomRight:
+		p.X = free.X
+	}
+	return p
+}
+
+// ThumbnailOptions configures Thumbnail. The zero value keeps the size of the
+// image, which makes a copy.
+//
+// Fields:
+//   - Width: The width of the thumbnail, 0 computes it from Height keeping the aspect ratio.
+//     With Fit it is the largest accepted width.
+//   - Height: The height of the thumbnail, 0 computes it from Width keeping the aspect ratio.
+//     With Fit it is the largest accepted height.
+//   - MaxWidth: The largest width of the thumbnail in every mode, 0 means no limit. A wider size
+//     is scaled down keeping its aspect ratio, so with Width and Height both 0 the image is
+//     fitted inside MaxWidth and MaxHeight.
+//   - MaxHeight: The largest height of the thumbnail in every mode, 0 means no limit
+//   - Mode: How the image fits the size, empty means Fit:
+//     fit - scales the image to fit inside the size, keeping the aspect ratio
+//     fill - scales the image to cover the size and crops the overflow around Anchor
+//     stretch - scales each side to the size, distorting the image
+//     pad - fits the image and centres it, or moves it to Anchor, on a Background canvas of the size
+//   - Anchor: The part of the image kept by fill and its position with pad, empty means Center
+//   - Filter: The resampling filter, empty means Lanczos3
+//   - Background: The canvas colour of pad, nil is transparent
+//   - NoUpscale: Never enlarge the image. Fill returns a smaller thumbnail with the aspect
+//     ratio of the size, stretch clamps each side to the image and pad centres the unscaled
+//     image on the canvas.
+type ThumbnailOptions struct {
+	Width      int
+	Height     int
+	MaxWidth   int
+	MaxHeight  int
+	Mode       Mode
+	Anchor     Anchor
+	Filter     Filter
+	Background color.Color
+	NoUpscale  bool
+}
+
+// targetSize returns the size requested by opts for img, completing a missing
+// side with NewAspectRatio or NewAspectRatioHeight and scaling it down the same
+// way to MaxWidth and MaxHeight.
+func (opts ThumbnailOptions) targetSize(img image.Image) image.Point {
+	width, height := opts.Width, opts.Height
+	switch {
+	case width <= 0 && height <= 0:
+		width, height = img.Bounds().Dx(), img.Bounds().Dy()
+	case height <= 0:
+		width, height = NewAspectRatio(img, width)
+	case width <= 0:
+		width, height = NewAspectRatioHeight(img, height)
+	}
+	width, height = max(width, 1), max(height, 1)
+
+	// A Rectangle is an image of its own size.
+	if opts.MaxWidth > 0 && width > opts.MaxWidth {
+		width, height = NewAspectRatio(image.Rect(0, 0, width, height), opts.MaxWidth)
+	}
+	if opts.MaxHeight > 0 && height > opts.MaxHeight {
+		width, height = NewAspectRatioHeight(image.Rect(0, 0, width, height), opts.MaxHeight)
+	}
+	return image.Point{max(width, 1), max(height, 1)}
+}
+
+// Thumbnail resizes an image to the size and mode of opts with Resample. Unknown
+// modes, anchors and filters are replaced with the defaults, use ThumbnailStrict
+// to reject them.
+//
+// Parameters:
+//   - img: The input image
+//   - opts: The size and mode of the thumbnail
+//
+// Returns:
+//   - image.Image: The thumbnail, its bounds start at (0, 0)
+//
+// Example:
+//
+//	thumb := resize.Thumbnail(img, resize.ThumbnailOptions{Width: 200, Height: 200, Mode: resize.Fill})
+func Thumbnail(img image.Image, opts ThumbnailOptions) image.Image {
+	newImage, _ := ThumbnailContext(context.Background(), img, opts, nil)
+	return newImage
+}
+
+// ThumbnailContext works like Thumbnail but aborts as soon as ctx is cancelled
+// and reports the progress of the resampling.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image
+//   - opts: The size and mode of the thumbnail
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The thumbnail, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func ThumbnailContext(ctx context.Context, img image.Image, opts ThumbnailOptions, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return image.NewRGBA64(image.Rectangle{}), nil
+	}
+
+	if _, ok := kernels[opts.Filter]; !ok {
+		opts.Filter = Lanczos3
+	}
+	size := bounds.Size()
+	target := opts.targetSize(img)
+
+	scaleX := float64(target.X) / float64(size.X)
+	scaleY := float64(target.Y) / float64(size.Y)
+
+	switch opts.Mode {
+	case Stretch:
+		if opts.NoUpscale {
+			target = image.Point{min(target.X, size.X), min(target.Y, size.Y)}
+		}
+		return ResampleContext(ctx, img, target.X, target.Y, opts.Filter, progress)
+
+	case Fill:
+		scale := max(scaleX, scaleY)
+		if opts.NoUpscale && scale > 1 {
+			// Shrink the thumbnail instead, keeping its aspect ratio.
+			target = image.Point{
+				max(int(math.Round(float64(target.X)/scale)), 1),
+				max(int(math.Round(float64(target.Y)/scale)), 1),
+			}
+			scale = 1
+		}
+
+		// Crop the source to the aspect ratio of the target first, so only
+		// the visible part is resampled.
+		crop := image.Point{
+			min(int(math.Round(float64(target.X)/scale)), size.X),
+			min(int(math.Round(float64(target.Y)/scale)), size.Y),
+		}
+		crop = image.Point{max(crop.X, 1), max(crop.Y, 1)}
+		origin := bounds.Min.Add(opts.Anchor.position(size.Sub(crop)))
+		return ResampleContext(ctx, subImage(img, image.Rectangle{origin, origin.Add(crop)}), target.X, target.Y, opts.Filter, progress)
+	}
+
+	// Fit, Pad and unknown modes.
+	scale := min(scaleX, scaleY)
+	if opts.NoUpscale {
+		scale = min(scale, 1)
+	}
+	fit := image.Point{
+		max(int(math.Round(float64(size.X)*scale)), 1),
+		max(int(math.Round(float64(size.Y)*scale)), 1),
+	}
+	newImage, err := ResampleContext(ctx, img, fit.X, fit.Y, opts.Filter, progress)
+	if err != nil || opts.Mode != Pad {
+		return newImage, err
+	}
+
+	canvas := image.NewRGBA64(image.Rect(0, 0, target.X, target.Y))
+	if opts.Background != nil {
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	}
+	position := opts.Anchor.position(target.Sub(fit))
+	draw.Draw(canvas, image.Rectangle{position, position.Add(fit)}, newImage, image.Point{}, draw.Over)
+	return canvas, nil
+}
+
+// ThumbnailStrict works like Thumbnail but returns an error for an unknown
+// mode, anchor or filter and for negative sizes. Empty values keep their defaults.
+//
+// Parameters:
+//   - img: The input image
+//   - opts: The size and mode of the thumbnail
+//
+// Returns:
+//   - image.Image: The thumbnail
+//   - error: A *utils.ValidationError describing the first invalid option
+func ThumbnailStrict(img image.Image, opts ThumbnailOptions) (image.Image, error) {
+	return ThumbnailStrictContext(context.Background(), img, opts, nil)
+}
+
+// ThumbnailStrictContext works like ThumbnailStrict but aborts as soon as ctx
+// is cancelled and reports progress like ThumbnailContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image
+//   - opts: The size and mode of the thumbnail
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The thumbnail, nil when cancelled or invalid
+//   - error: A *utils.ValidationError describing the first invalid option, or ctx.Err()
+//     when the context was cancelled
+func ThumbnailStrictContext(ctx context.Context, img image.Image, opts ThumbnailOptions, progress utils.ProgressFunc) (image.Image, error) {
+	if err := utils.ValidateRange("width", opts.Width, 0, 65535); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("height", opts.Height, 0, 65535); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("max-width", opts.MaxWidth, 0, 65535); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("max-height", opts.MaxHeight, 0, 65535); err != nil {
+		return nil, err
+	}
+	if opts.Mode != "" {
+		if err := utils.ValidateOption("mode", opts.Mode, Modes()); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Anchor != "" {
+		if err := utils.ValidateOption("anchor", opts.Anchor, Anchors()); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Filter != "" {
+		if err := utils.ValidateOption("filter", opts.Filter, Filters()); err != nil {
+			return nil, err
+		}
+	}
+	return ThumbnailContext(ctx, img, opts, progress)
+}
+
+// subImage returns the part of img inside r, sharing the pixels when img
+// supports SubImage and copying them otherwise.
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	newImage := image.NewRGBA64(r)
+	draw.Draw(newImage, r, img, r.Min, draw.Src)
+	return newImage
+}
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -29,17 +30,19 @@ func AdjustLevels(img image.Image, red, green, blue int) image.Image {
 	blue = utils.ClampGeneric(blue, 1, 100)
 
 	bounds := img.Bounds()
-	newImage := image.NewRGBA64(bounds)
+	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 
 			rr := (r * uint32(red)) / 100
 			gg := (g * uint32(green)) / 100
 			bb := (b * uint32(blue)) / 100
 
-			newImage.Set(x, y, color.RGBA64{
+			newImage.SetNRGBA64(x, y, color.NRGBA64{
 				uint16(rr),
 				uint16(gg),
 				uint16(bb),
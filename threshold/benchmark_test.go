@@ -0,0 +1,21 @@
+package threshold_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/BrunoPoiano/imgeffects/effects/effectstest"
+	"github.com/BrunoPoiano/imgeffects/threshold"
+)
+
+func BenchmarkGlobalThreshold(b *testing.B) {
+	effectstest.BenchmarkPixelAccess(b, func(img image.Image) image.Image {
+		return threshold.GlobalThreshold(img, 50)
+	})
+}
+
+func BenchmarkMultiThreshold(b *testing.B) {
+	effectstest.BenchmarkPixelAccess(b, func(img image.Image) image.Image {
+		return threshold.MultiThreshold(img, 4)
+	})
+}
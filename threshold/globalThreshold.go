@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -20,15 +21,35 @@ import (
 // Returns:
 //   - image.Image: A new grayscale image with binary (black and white) pixels
 func GlobalThreshold(img image.Image, level int) image.Image {
+	return globalThreshold(img, level, false)
+}
+
+// GlobalThresholdKeepAlpha works like GlobalThreshold but leaves the alpha
+// channel untouched, returning black and white pixels with the transparency
+// of the source.
+//
+// Parameters:
+//   - img: The input image to be thresholded
+//   - level: The threshold level, ranging from 1 to 100
+//
+// Returns:
+//   - image.Image: An *image.NRGBA with black and white pixels
+func GlobalThresholdKeepAlpha(img image.Image, level int) image.Image {
+	return globalThreshold(img, level, true)
+}
+
+func globalThreshold(img image.Image, level int, keepAlpha bool) image.Image {
 	bounds := img.Bounds()
-	newImage := image.NewGray(bounds)
+	newImage := newGrayOutput(bounds, keepAlpha)
+	reader := pixel.NewReader(img)
 	level = utils.ClampGeneric(level, 1, 100)
 	treshold_level := (255 * level) / 100
 
-	for y := 1; y < bounds.Max.Y-1; y++ {
-		for x := 1; x < bounds.Max.X-1; x++ {
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x++ {
 
-			r, g, b, _ := img.At(x, y).RGBA()
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			pixel := utils.Luminance8bit(r, g, b)
 
 			if pixel > float64(treshold_level) {
@@ -37,11 +58,11 @@ func GlobalThreshold(img image.Image, level int) image.Image {
 				pixel = 0
 			}
 
-			newImage.Set(x, y, color.Gray{uint8(pixel)})
+			newImage.set(x, y, uint8(pixel), a)
 		}
 	}
 
-	return newImage
+	return newImage.image()
 
 }
 
@@ -61,6 +82,7 @@ func GlobalThreshold(img image.Image, level int) image.Image {
 func GlobalThresholdColor(img image.Image, level int) image.Image {
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 	level = utils.ClampGeneric(level, 1, 100)
 	treshold_level := (65535 * level) / 100
 
@@ -74,12 +96,13 @@ func GlobalThresholdColor(img image.Image, level int) image.Image {
 		return uint16(c)
 	}
 
-	for y := 1; y < bounds.Max.Y-1; y++ {
-		for x := 1; x < bounds.Max.X-1; x++ {
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x++ {
 
-			r, g, b, a := img.At(x, y).RGBA()
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 
-			newImage.Set(x, y, color.RGBA64{colorFun(r), colorFun(g), colorFun(b), uint16(a)})
+			newImage.SetNRGBA64(x, y, color.NRGBA64{colorFun(r), colorFun(g), colorFun(b), uint16(a)})
 		}
 	}
 
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
@@ -19,10 +20,28 @@ import (
 // Returns:
 //   - image.Image
 func MultiThreshold(img image.Image, quantity int) image.Image {
+	return multiThreshold(img, quantity, false)
+}
+
+// MultiThresholdKeepAlpha works like MultiThreshold but leaves the alpha
+// channel untouched, returning the gray bands with the transparency of the source.
+//
+// Parameters:
+//   - img: The input image to be processed
+//   - quantity: The number of thresholds to apply, must be between 2-100 (will be clamped)
+//
+// Returns:
+//   - image.Image: An *image.NRGBA with gray pixels
+func MultiThresholdKeepAlpha(img image.Image, quantity int) image.Image {
+	return multiThreshold(img, quantity, true)
+}
+
+func multiThreshold(img image.Image, quantity int, keepAlpha bool) image.Image {
 	quantity = utils.ClampGeneric(quantity, 2, 100)
 
 	bounds := img.Bounds()
-	newImage := image.NewGray(bounds)
+	newImage := newGrayOutput(bounds, keepAlpha)
+	reader := pixel.NewReader(img)
 	levels := 100 / quantity
 
 	var thresholds []int
@@ -38,10 +57,11 @@ func MultiThreshold(img image.Image, quantity int) image.Image {
 	}
 	thresholds = append(thresholds, 0)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-			r, g, b, _ := img.At(x, y).RGBA()
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			pixel := utils.Luminance8bit(r, g, b)
 
 			for _, t := range thresholds {
@@ -51,11 +71,11 @@ func MultiThreshold(img image.Image, quantity int) image.Image {
 				}
 			}
 
-			newImage.Set(x, y, color.Gray{uint8(pixel)})
+			newImage.set(x, y, uint8(pixel), a)
 		}
 	}
 
-	return newImage
+	return newImage.image()
 
 }
 
@@ -75,6 +95,7 @@ func MultiThresholdColor(img image.Image, quantity int) image.Image {
 
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 	levels := 100 / quantity
 
 	var thresholds []int
@@ -90,10 +111,11 @@ func MultiThresholdColor(img image.Image, quantity int) image.Image {
 	}
 	thresholds = append(thresholds, 0)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-			r, g, b, a := img.At(x, y).RGBA()
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			var rr, gg, bb uint16
 
 			for _, t := range thresholds {
@@ -116,7 +138,7 @@ func MultiThresholdColor(img image.Image, quantity int) image.Image {
 				}
 			}
 
-			newImage.Set(x, y, color.RGBA64{rr, gg, bb, uint16(a)})
+			newImage.SetNRGBA64(x, y, color.NRGBA64{rr, gg, bb, uint16(a)})
 		}
 	}
 
@@ -4,9 +4,40 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/BrunoPoiano/imgeffects/internal/pixel"
 	"github.com/BrunoPoiano/imgeffects/utils"
 )
 
+// grayOutput is the image written by the gray threshold effects: an *image.Gray,
+// or an *image.NRGBA keeping the alpha of the source when keepAlpha is set.
+type grayOutput struct {
+	gray  *image.Gray
+	nrgba *image.NRGBA
+}
+
+func newGrayOutput(bounds image.Rectangle, keepAlpha bool) grayOutput {
+	if keepAlpha {
+		return grayOutput{nrgba: image.NewNRGBA(bounds)}
+	}
+	return grayOutput{gray: image.NewGray(bounds)}
+}
+
+// set stores the gray value v at (x, y), with the 16-bit alpha a of the source pixel.
+func (o grayOutput) set(x, y int, v uint8, a uint32) {
+	if o.nrgba != nil {
+		o.nrgba.SetNRGBA(x, y, color.NRGBA{v, v, v, uint8(a >> 8)})
+		return
+	}
+	o.gray.SetGray(x, y, color.Gray{v})
+}
+
+func (o grayOutput) image() image.Image {
+	if o.nrgba != nil {
+		return o.nrgba
+	}
+	return o.gray
+}
+
 // ThresholdRGB processes an image pixel by pixel. For each pixel, it determines
 // which color channel (Red, Green, or Blue) has the highest value. It then creates
 // a new pixel where only the dominant color channel's value is retained, setting
@@ -24,11 +55,13 @@ func ThresholdRGB(img image.Image) image.Image {
 
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
+	writer := pixel.NewWriter(newImage)
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-			r, g, b, a := img.At(x, y).RGBA()
+			r, g, b, a := reader.RGBA(x, y)
 
 			var rr, gg, bb uint32
 			if r >= g && r >= b {
@@ -39,7 +72,7 @@ func ThresholdRGB(img image.Image) image.Image {
 				bb = b
 			}
 
-			newImage.Set(x, y, color.RGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
+			writer.SetRGBA(x, y, rr, gg, bb, a)
 		}
 	}
 
@@ -77,8 +110,9 @@ func MultiThresholdRGB(img image.Image, c1, c2, c3 string) image.Image {
 
 	bounds := img.Bounds()
 	newImage := image.NewNRGBA64(bounds)
+	reader := pixel.NewReader(img)
 
-	newColor := func(r, g, b, a uint32, c string) color.Color {
+	newColor := func(r, g, b uint32, c string) (uint32, uint32, uint32) {
 
 		var rr, gg, bb uint32
 
@@ -101,37 +135,41 @@ func MultiThresholdRGB(img image.Image, c1, c2, c3 string) image.Image {
 			bb = b
 		}
 
-		return color.RGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)}
+		return rr, gg, bb
 
 	}
 
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 
-			r, g, b, a := img.At(x, y).RGBA()
+			r, g, b, a := reader.RGBA(x, y)
+			r, g, b = utils.Unpremultiply(r, g, b, a)
 			pixel := utils.Luminance16bit(r, g, b)
 
 			if pixel >= 53083 { // ~81%
 				rr := uint32(65535)
 				gg := uint32(65535)
 				bb := uint32(65535)
-				newImage.Set(x, y, color.RGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
+				newImage.SetNRGBA64(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
 
 			} else if pixel >= 39976 { // ~61%
 
-				newImage.Set(x, y, newColor(r, g, b, a, c1))
+				rr, gg, bb := newColor(r, g, b, c1)
+				newImage.SetNRGBA64(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
 			} else if pixel >= 26869 { // ~41%
 
-				newImage.Set(x, y, newColor(r, g, b, a, c2))
+				rr, gg, bb := newColor(r, g, b, c2)
+				newImage.SetNRGBA64(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
 			} else if pixel >= 13762 { // ~21%
 
-				newImage.Set(x, y, newColor(r, g, b, a, c3))
+				rr, gg, bb := newColor(r, g, b, c3)
+				newImage.SetNRGBA64(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
 			} else { // < 21%
 				rr := uint32(0)
 				gg := uint32(0)
 				bb := uint32(0)
 
-				newImage.Set(x, y, color.RGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
+				newImage.SetNRGBA64(x, y, color.NRGBA64{uint16(rr), uint16(gg), uint16(bb), uint16(a)})
 
 			}
 		}
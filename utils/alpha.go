@@ -0,0 +1,42 @@
+package utils
+
+// The effects follow one alpha policy: filters mixing neighbouring pixels
+// (blurs, resizes, sharpening) work on premultiplied values, as returned by
+// color.Color.RGBA, while per-pixel colour math (levels, curves, hue,
+// thresholds, quantization) works on straight values obtained with
+// Unpremultiply and converted back with Premultiply, so semi-transparent
+// pixels keep their colour instead of turning darker.
+
+// Unpremultiply converts premultiplied 16-bit channels to straight ones.
+// Fully transparent pixels return black.
+//
+// Parameters:
+//   - r, g, b: The premultiplied colour channels (0-65535)
+//   - a: The alpha channel (0-65535)
+//
+// Returns:
+//   - r, g, b: The straight colour channels (0-65535)
+func Unpremultiply(r, g, b, a uint32) (uint32, uint32, uint32) {
+	switch a {
+	case 0:
+		return 0, 0, 0
+	case 0xffff:
+		return r, g, b
+	}
+	return min(r*0xffff/a, 0xffff), min(g*0xffff/a, 0xffff), min(b*0xffff/a, 0xffff)
+}
+
+// Premultiply converts straight 16-bit channels to premultiplied ones.
+//
+// Parameters:
+//   - r, g, b: The straight colour channels (0-65535)
+//   - a: The alpha channel (0-65535)
+//
+// Returns:
+//   - r, g, b: The premultiplied colour channels (0-65535)
+func Premultiply(r, g, b, a uint32) (uint32, uint32, uint32) {
+	if a == 0xffff {
+		return r, g, b
+	}
+	return r * a / 0xffff, g * a / 0xffff, b * a / 0xffff
+}
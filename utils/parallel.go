@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"context"
+	"image"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ProgressFunc receives progress updates of a parallel execution.
+//
+// Parameters:
+//   - done: Number of rows completed so far
+//   - total: Total number of rows
+type ProgressFunc func(done, total int)
+
+// Options configures how ParallelExecution distributes the work.
+//
+// Fields:
+//   - MaxWorkers: Maximum number of goroutines used by a single call (defaults to runtime.GOMAXPROCS(0) if <= 0).
+//     With 1 the rows are processed in the calling goroutine, which suits servers
+//     that already run one request per goroutine. Those calls share the CPU slots
+//     too, so many concurrent requests never run more chunks than there are CPUs.
+//   - ChunkSize: Number of rows handed to a goroutine at a time. Defaults, if <= 0,
+//     to a size giving every worker several chunks, which balances uneven rows and
+//     keeps cancellation and progress reporting responsive.
+//   - Logger: Optional function receiving debug messages, nil disables logging
+type Options struct {
+	MaxWorkers int
+	ChunkSize  int
+	Logger     func(format string, args ...any)
+}
+
+// ParallelExecutionStruct contains parameters for parallel image processing.
+//
+// Fields:
+//   - Image: The source image to be processed
+//   - Function: The function to apply to image regions (params: start y, end y, output image, waitgroup).
+//     The rows range over the image bounds, from Bounds().Min.Y to Bounds().Max.Y.
+//   - EndSize: Optional number of work items, when > 0 the function receives ranges of [0, EndSize)
+//     instead of rows (e.g. a number of points to draw)
+//   - Progress: Optional callback invoked every time a group of rows is completed
+//   - Options: Optional per call options, nil uses the options set with SetDefaultOptions
+//   - NoOutput: When true no output image is allocated, the function receives nil and
+//     the execution returns a nil image. Used by functions writing into an image of
+//     another type (e.g. a *linear.Image).
+type ParallelExecutionStruct struct {
+	Image    image.Image
+	Function func(int, int, *image.RGBA64, *sync.WaitGroup)
+	EndSize  int
+	Progress ProgressFunc
+	Options  *Options
+	NoOutput bool
+}
+
+// chunksPerWorker is the number of row groups handed to each goroutine
+// when Options.ChunkSize is not set.
+const chunksPerWorker = 8
+
+// cpuSlots bounds the number of ParallelExecution goroutines running at the same
+// time across the whole process, so concurrent callers (e.g. batch workers
+// processing several images at once) share the CPUs instead of each one
+// starting its own set of goroutines.
+var cpuSlots = make(chan struct{}, runtime.NumCPU())
+
+var (
+	defaultOptionsMu sync.RWMutex
+	defaultOptions   Options
+)
+
+// SetDefaultOptions sets the options used by every ParallelExecution call
+// that does not provide its own.
+//
+// Parameters:
+//   - opts: The new default options
+func SetDefaultOptions(opts Options) {
+	defaultOptionsMu.Lock()
+	defer defaultOptionsMu.Unlock()
+	defaultOptions = opts
+}
+
+// DefaultOptions returns the options set with SetDefaultOptions.
+//
+// Returns:
+//   - Options
+func DefaultOptions() Options {
+	defaultOptionsMu.RLock()
+	defer defaultOptionsMu.RUnlock()
+	return defaultOptions
+}
+
+// ParallelExecution processes an image in parallel using multiple CPU cores.
+// It distributes the workload by dividing the image into horizontal strips
+// and processing each strip concurrently with the provided function.
+// The number of strips running at the same time across all callers is bounded
+// by the number of CPUs. The number of goroutines and the strip size are set
+// through Options, per call or globally with SetDefaultOptions.
+//
+// The processing function must not call ParallelExecution itself: a strip
+// holds a CPU slot while it runs, so nested calls waiting for another slot
+// deadlock once every slot is taken. A panic in the processing function is
+// raised again in the calling goroutine once the other strips finished, the
+// CPU slots being released.
+//
+// Parameters:
+//   - exec: ParallelExecutionStruct containing the image, processing function, and optional size limit
+//
+// Returns:
+//   - image.Image: The resulting processed RGBA64 image
+func ParallelExecution(exec ParallelExecutionStruct) image.Image {
+	newImage, _ := ParallelExecutionContext(context.Background(), exec)
+	return newImage
+}
+
+// ParallelExecutionContext works like ParallelExecution but stops handing out
+// strips as soon as ctx is cancelled. Strips already running finish unless the
+// processing function checks ctx itself, which long running effects should do
+// once per row.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - exec: ParallelExecutionStruct containing the image, processing function, and optional size limit
+//
+// Returns:
+//   - image.Image: The resulting processed RGBA64 image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func ParallelExecutionContext(ctx context.Context, exec ParallelExecutionStruct) (image.Image, error) {
+	bounds := exec.Image.Bounds()
+	var newImage *image.RGBA64
+	if !exec.NoOutput {
+		newImage = image.NewRGBA64(bounds)
+	}
+	first, endSize := bounds.Min.Y, bounds.Max.Y
+
+	if exec.EndSize > 0 {
+		first, endSize = 0, exec.EndSize
+	}
+	total := endSize - first
+
+	opts := DefaultOptions()
+	if exec.Options != nil {
+		opts = *exec.Options
+	}
+
+	workers := opts.MaxWorkers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	chunk := opts.ChunkSize
+	if chunk <= 0 {
+		chunk = (total + workers*chunksPerWorker - 1) / (workers * chunksPerWorker)
+	}
+	chunk = max(chunk, 1)
+	workers = max(min(workers, (total+chunk-1)/chunk), 1)
+
+	if opts.Logger != nil {
+		opts.Logger("parallel execution: %d rows, %d workers, %d rows per chunk", total, workers, chunk)
+	}
+
+	if workers == 1 {
+		return sequentialExecution(ctx, exec, newImage, first, endSize, chunk)
+	}
+
+	var (
+		functionWg sync.WaitGroup
+		workersWg  sync.WaitGroup
+		progressMu sync.Mutex
+		done       int
+		panicked   atomic.Bool
+		panicValue any
+	)
+	starts := make(chan int)
+
+	workersWg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer workersWg.Done()
+			for start := range starts {
+				end := min(start+chunk, endSize)
+				// The remaining strips are skipped after a panic, still
+				// receiving them so the dispatch does not block.
+				if panicked.Load() {
+					continue
+				}
+				if !runRecovered(exec, start, end, newImage, &functionWg, &panicked, &panicValue) {
+					continue
+				}
+
+				if exec.Progress != nil {
+					progressMu.Lock()
+					done += end - start
+					exec.Progress(done, total)
+					progressMu.Unlock()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for start := first; start < endSize; start += chunk {
+		select {
+		case starts <- start:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(starts)
+	workersWg.Wait()
+
+	if panicked.Load() {
+		panic(panicValue)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if exec.NoOutput {
+		return nil, nil
+	}
+	return newImage, nil
+}
+
+// runWithSlot runs the processing function on the rows [start, end) once a
+// CPU slot is available, releasing the slot even when the function panics.
+func runWithSlot(exec ParallelExecutionStruct, start, end int, newImage *image.RGBA64, wg *sync.WaitGroup) {
+	cpuSlots <- struct{}{}
+	defer func() { <-cpuSlots }()
+	wg.Add(1)
+	exec.Function(start, end, newImage, wg)
+}
+
+// runRecovered works like runWithSlot in a worker goroutine, where a panic
+// cannot reach the caller: the first panic value is stored in value and
+// flagged in panicked instead. It reports whether the function returned
+// normally.
+func runRecovered(exec ParallelExecutionStruct, start, end int, newImage *image.RGBA64, wg *sync.WaitGroup, panicked *atomic.Bool, value *any) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil && panicked.CompareAndSwap(false, true) {
+			*value = r
+		}
+	}()
+	runWithSlot(exec, start, end, newImage, wg)
+	return true
+}
+
+// sequentialExecution processes every chunk in the calling goroutine, still
+// taking a CPU slot for each one so it shares the CPUs with the other calls.
+func sequentialExecution(ctx context.Context, exec ParallelExecutionStruct, newImage *image.RGBA64, first, endSize, chunk int) (image.Image, error) {
+	var wg sync.WaitGroup
+
+	for start := first; start < endSize; start += chunk {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := min(start+chunk, endSize)
+		runWithSlot(exec, start, end, newImage, &wg)
+
+		if exec.Progress != nil {
+			exec.Progress(end-first, endSize-first)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if exec.NoOutput {
+		return nil, nil
+	}
+	return newImage, nil
+}
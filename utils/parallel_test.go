@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"image"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestParallelExecutionPanicReleasesSlots panics inside a strip more times
+// than there are CPU slots, on the sequential and the parallel paths, and
+// checks that every panic reaches the caller and every slot is given back.
+func TestParallelExecutionPanicReleasesSlots(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 64))
+
+	for _, workers := range []int{1, 4} {
+		exec := ParallelExecutionStruct{
+			Image: img,
+			Function: func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+				defer wg.Done()
+				if start <= 32 && 32 < end {
+					panic("strip failed")
+				}
+			},
+			Options: &Options{MaxWorkers: workers, ChunkSize: 4},
+		}
+
+		for range runtime.NumCPU() + 1 {
+			func() {
+				defer func() {
+					if r := recover(); r != "strip failed" {
+						t.Fatalf("workers %d: recovered %v, want the panic of the strip", workers, r)
+					}
+				}()
+				ParallelExecution(exec)
+			}()
+			if n := len(cpuSlots); n != 0 {
+				t.Fatalf("workers %d: %d CPU slots still taken after the panic", workers, n)
+			}
+		}
+	}
+
+	// A later call still gets the slots it needs.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ParallelExecution(ParallelExecutionStruct{
+			Image:    img,
+			Function: func(_, _ int, _ *image.RGBA64, wg *sync.WaitGroup) { wg.Done() },
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ParallelExecution blocked after the recovered panics")
+	}
+}
+
+// TestParallelExecutionContextCancelled checks that a cancelled execution
+// returns the context error and releases its slots.
+func TestParallelExecutionContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := ParallelExecutionContext(ctx, ParallelExecutionStruct{
+		Image: image.NewRGBA(image.Rect(0, 0, 16, 64)),
+		Function: func(_, _ int, _ *image.RGBA64, wg *sync.WaitGroup) {
+			defer wg.Done()
+			cancel()
+		},
+		Options: &Options{MaxWorkers: 2, ChunkSize: 1},
+	})
+	if err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if n := len(cpuSlots); n != 0 {
+		t.Fatalf("%d CPU slots still taken after the cancellation", n)
+	}
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"math/rand/v2"
+)
+
+// NewSource returns a random source producing the same sequence for the same seed.
+// Passing it to the WithSource variants of the random effects makes their
+// results reproducible, whatever the number of goroutines used.
+//
+// Parameters:
+//   - seed: The seed of the sequence
+//
+// Returns:
+//   - rand.Source
+func NewSource(seed uint64) rand.Source {
+	return rand.NewPCG(seed, 0x9e3779b97f4a7c15)
+}
+
+// NewRand returns a generator reading from src. A nil src is replaced by a
+// randomly seeded one, so the results change on every call.
+//
+// Parameters:
+//   - src: The random source, may be nil
+//
+// Returns:
+//   - *rand.Rand
+func NewRand(src rand.Source) *rand.Rand {
+	if src == nil {
+		src = rand.NewPCG(rand.Uint64(), rand.Uint64())
+	}
+	return rand.New(src)
+}
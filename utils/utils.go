@@ -1,70 +1,12 @@
 package utils
 
 import (
-	"image"
+	"fmt"
 	"image/color"
-	"runtime"
-	"sync"
+	"strconv"
+	"strings"
 )
 
-// ParallelExecutionStruct contains parameters for parallel image processing.
-//
-// Fields:
-//   - Image: The source image to be processed
-//   - Function: The function to apply to image regions (params: start y, end y, output image, waitgroup)
-//   - EndSize: Optional limit for the y-coordinate (defaults to image height if <= 0)
-type ParallelExecutionStruct struct {
-	Image    image.Image
-	Function func(int, int, *image.RGBA64, *sync.WaitGroup)
-	EndSize  int
-}
-
-// ParallelExecution processes an image in parallel using multiple CPU cores.
-// It distributes the workload by dividing the image into horizontal strips
-// and processing each strip concurrently with the provided function.
-//
-// Parameters:
-//   - exec: ParallelExecutionStruct containing the image, processing function, and optional size limit
-//
-// Returns:
-//   - image.Image: The resulting processed RGBA64 image
-func ParallelExecution(exec ParallelExecutionStruct) image.Image {
-	var wg sync.WaitGroup
-	bounds := exec.Image.Bounds()
-	newImage := image.NewRGBA64(bounds)
-	cpus_available := runtime.NumCPU()
-	endSize := bounds.Max.Y
-
-	if exec.EndSize > 0 {
-		endSize = exec.EndSize
-	}
-
-	if cpus_available < 4 {
-		wg.Add(1)
-		go exec.Function(0, endSize, newImage, &wg)
-
-	} else {
-		cpus_available--
-		println("using", cpus_available, "cpus")
-
-		wg.Add(cpus_available)
-		div := (endSize + cpus_available - 1) / cpus_available
-		start := 0
-
-		for i := 0; i < cpus_available; i++ {
-			end := start + div
-			if end > endSize {
-				end = endSize
-			}
-			go exec.Function(start, end, newImage, &wg)
-			start = end
-		}
-	}
-
-	wg.Wait()
-	return newImage
-}
-
 // Luminance8bit calculates the luminance of an image (0-255) using the formula:
 // L = 0.299*R + 0.587*G + 0.114*B
 //
@@ -191,3 +133,33 @@ func ColorAverage(colors []color.Color) color.Color {
 		A: uint16(a / c_l),
 	}
 }
+
+// ParseHexColor parses a CSS style hexadecimal colour, "#rgb", "#rgba",
+// "#rrggbb" or "#rrggbbaa". The leading "#" is optional.
+//
+// Parameters:
+//   - s: The colour to parse
+//
+// Returns:
+//   - color.NRGBA: The colour, opaque when s has no alpha digits
+//   - error: When s is not a hexadecimal colour
+func ParseHexColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 || len(hex) == 4 {
+		var long strings.Builder
+		for _, digit := range hex {
+			long.WriteRune(digit)
+			long.WriteRune(digit)
+		}
+		hex = long.String()
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q: must be #rgb, #rgba, #rrggbb or #rrggbbaa", s)
+	}
+	return color.NRGBA{uint8(value >> 24), uint8(value >> 16), uint8(value >> 8), uint8(value)}, nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError reports a parameter value that an effect does not accept.
+// It is returned by the error returning variants of the effects, which reject
+// invalid values instead of clamping or ignoring them.
+//
+// Fields:
+//   - Param: The name of the parameter
+//   - Value: The rejected value
+//   - Allowed: The accepted values, empty for numeric parameters
+//   - Min: The smallest accepted value of a numeric parameter
+//   - Max: The largest accepted value of a numeric parameter
+type ValidationError struct {
+	Param   string
+	Value   any
+	Allowed []string
+	Min     float64
+	Max     float64
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Allowed) > 0 {
+		return fmt.Sprintf("invalid %s %q: must be one of %s", e.Param, fmt.Sprint(e.Value), strings.Join(e.Allowed, ", "))
+	}
+	return fmt.Sprintf("invalid %s %v: must be between %v and %v", e.Param, e.Value, e.Min, e.Max)
+}
+
+// ValidateRange returns a *ValidationError when value is outside [min, max].
+//
+// Parameters:
+//   - param: The name of the parameter, used in the error
+//   - value: The value to check
+//   - min: The smallest accepted value
+//   - max: The largest accepted value
+//
+// Returns:
+//   - error: nil when the value is accepted
+func ValidateRange[T int | float64](param string, value, min, max T) error {
+	if value < min || value > max {
+		return &ValidationError{Param: param, Value: value, Min: float64(min), Max: float64(max)}
+	}
+	return nil
+}
+
+// ValidateOption returns a *ValidationError when value is not one of allowed.
+//
+// Parameters:
+//   - param: The name of the parameter, used in the error
+//   - value: The value to check
+//   - allowed: The accepted values
+//
+// Returns:
+//   - error: nil when the value is accepted
+func ValidateOption[T ~string](param string, value T, allowed []T) error {
+	for _, option := range allowed {
+		if value == option {
+			return nil
+		}
+	}
+
+	names := make([]string, len(allowed))
+	for i, option := range allowed {
+		names[i] = string(option)
+	}
+	return &ValidationError{Param: param, Value: string(value), Allowed: names}
+}
@@ -0,0 +1,105 @@
+package warp
+
+import (
+	"context"
+	"image"
+	"math"
+
+	"github.com/BrunoPoiano/imgeffects/resize"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// LensDistortion applies or corrects radial lens distortion with the
+// Brown-Conrady model. Each pixel of the result at the normalised distance r
+// from the centre is sampled at the distance r * (1 + k1*r^2 + k2*r^4) of
+// the source, r being 1 at the corners.
+// Negative coefficients correct barrel distortion (lines bowing outwards) and
+// create pincushion distortion; positive ones correct pincushion distortion
+// and create barrel distortion.
+//
+// Parameters:
+//   - img: The input image
+//   - k1: The quadratic coefficient, usually between -1 and 1
+//   - k2: The quartic coefficient, refining the corners
+//   - opts: The resampling filter and the colour of the uncovered areas
+//
+// Returns:
+//   - image.Image: A new RGBA64 image with the size of img
+func LensDistortion(img image.Image, k1, k2 float64, opts Options) image.Image {
+	newImage, _ := LensDistortionContext(context.Background(), img, k1, k2, opts, nil)
+	return newImage
+}
+
+// LensDistortionContext works like LensDistortion but aborts as soon as ctx
+// is cancelled and reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image
+//   - k1: The quadratic coefficient
+//   - k2: The quartic coefficient
+//   - opts: The resampling filter and the colour of the uncovered areas
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The distorted image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func LensDistortionContext(ctx context.Context, img image.Image, k1, k2 float64, opts Options, progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	cx, cy := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	radius := math.Hypot(cx, cy)
+
+	source := func(x, y float64) (float64, float64, bool) {
+		dx, dy := (x-cx)/radius, (y-cy)/radius
+		r2 := dx*dx + dy*dy
+		scale := 1 + k1*r2 + k2*r2*r2
+		return cx + dx*scale*radius, cy + dy*scale*radius, true
+	}
+	return remap(ctx, img, bounds.Dx(), bounds.Dy(), opts, source, progress)
+}
+
+// LensDistortionStrict works like LensDistortion but returns an error for
+// coefficients out of [-1, 1] or an unknown filter.
+//
+// Parameters:
+//   - img: The input image
+//   - k1: The quadratic coefficient (-1 to 1)
+//   - k2: The quartic coefficient (-1 to 1)
+//   - opts: The resampling filter and the colour of the uncovered areas
+//
+// Returns:
+//   - image.Image
+//   - error: A *utils.ValidationError describing the first invalid parameter
+func LensDistortionStrict(img image.Image, k1, k2 float64, opts Options) (image.Image, error) {
+	return LensDistortionStrictContext(context.Background(), img, k1, k2, opts, nil)
+}
+
+// LensDistortionStrictContext works like LensDistortionStrict but aborts as
+// soon as ctx is cancelled and reports progress like LensDistortionContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image
+//   - k1: The quadratic coefficient (-1 to 1)
+//   - k2: The quartic coefficient (-1 to 1)
+//   - opts: The resampling filter and the colour of the uncovered areas
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The distorted image, nil when cancelled or invalid
+//   - error: A *utils.ValidationError describing the first invalid parameter, or ctx.Err()
+//     when the context was cancelled
+func LensDistortionStrictContext(ctx context.Context, img image.Image, k1, k2 float64, opts Options, progress utils.ProgressFunc) (image.Image, error) {
+	if err := utils.ValidateRange("k1", k1, -1, 1); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("k2", k2, -1, 1); err != nil {
+		return nil, err
+	}
+	if opts.Filter != "" {
+		if err := utils.ValidateOption("filter", opts.Filter, resize.Filters()); err != nil {
+			return nil, err
+		}
+	}
+	return LensDistortionContext(ctx, img, k1, k2, opts, progress)
+}
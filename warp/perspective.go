@@ -0,0 +1,249 @@
+package warp
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+
+	"github.com/BrunoPoiano/imgeffects/resize"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// ErrDegenerate is returned when the corners of a perspective warp do not
+// define a homography, e.g. when three of them lie on a line.
+var ErrDegenerate = errors.New("warp: degenerate corner points")
+
+// Homography is a projective transform mapping the point (x, y) to
+// ((h[0]*x + h[1]*y + h[2]) / w, (h[3]*x + h[4]*y + h[5]) / w), with
+// w = h[6]*x + h[7]*y + h[8].
+type Homography [9]float64
+
+// NewHomography returns the homography mapping each of the four from points
+// to the matching to point.
+//
+// Parameters:
+//   - from: The source points
+//   - to: The destination points
+//
+// Returns:
+//   - Homography
+//   - error: ErrDegenerate when the points do not define a homography
+func NewHomography(from, to [4]Point) (Homography, error) {
+	// Each pair gives two rows of the linear system of the first eight
+	// coefficients, the last one being 1.
+	var system [8][9]float64
+	for i := range 4 {
+		x, y, u, v := from[i].X, from[i].Y, to[i].X, to[i].Y
+		system[2*i] = [9]float64{x, y, 1, 0, 0, 0, -x * u, -y * u, u}
+		system[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -x * v, -y * v, v}
+	}
+
+	// Gaussian elimination with partial pivoting.
+	for col := range 8 {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(system[row][col]) > math.Abs(system[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(system[pivot][col]) < 1e-12 {
+			return Homography{}, ErrDegenerate
+		}
+		system[col], system[pivot] = system[pivot], system[col]
+
+		for row := range 8 {
+			if row == col {
+				continue
+			}
+			factor := system[row][col] / system[col][col]
+			for k := col; k < 9; k++ {
+				system[row][k] -= factor * system[col][k]
+			}
+		}
+	}
+
+	var h Homography
+	for i := range 8 {
+		h[i] = system[i][8] / system[i][i]
+	}
+	h[8] = 1
+	if _, ok := h.Invert(); !ok {
+		return Homography{}, ErrDegenerate
+	}
+	return h, nil
+}
+
+// Apply returns the point (x, y) transformed by h.
+//
+// Returns:
+//   - float64, float64: The transformed point
+//   - bool: false when the point is sent to infinity
+func (h Homography) Apply(x, y float64) (float64, float64, bool) {
+	w := h[6]*x + h[7]*y + h[8]
+	if math.Abs(w) < 1e-12 {
+		return 0, 0, false
+	}
+	return (h[0]*x + h[1]*y + h[2]) / w, (h[3]*x + h[4]*y + h[5]) / w, true
+}
+
+// Invert returns the homography undoing h.
+//
+// Returns:
+//   - Homography
+//   - bool: false when h is not invertible
+func (h Homography) Invert() (Homography, bool) {
+	a := h[4]*h[8] - h[5]*h[7]
+	b := h[5]*h[6] - h[3]*h[8]
+	c := h[3]*h[7] - h[4]*h[6]
+	det := h[0]*a + h[1]*b + h[2]*c
+	if math.Abs(det) < 1e-12 || math.IsNaN(det) {
+		return Homography{}, false
+	}
+	return Homography{
+		a / det, (h[2]*h[7] - h[1]*h[8]) / det, (h[1]*h[5] - h[2]*h[4]) / det,
+		b / det, (h[0]*h[8] - h[2]*h[6]) / det, (h[2]*h[3] - h[0]*h[5]) / det,
+		c / det, (h[1]*h[6] - h[0]*h[7]) / det, (h[0]*h[4] - h[1]*h[3]) / det,
+	}, true
+}
+
+// Warp transforms an image with the homography h, sampling each pixel of the
+// result at the source position h maps onto it. The coordinates of h are
+// relative to the top left corner of img, and the result starts at the
+// origin of img.
+//
+// Parameters:
+//   - img: The input image
+//   - h: The transform from the source to the result coordinates
+//   - width: The width of the result (in pixels)
+//   - height: The height of the result (in pixels)
+//   - opts: The resampling filter and the background colour
+//
+// Returns:
+//   - image.Image: A new RGBA64 image, filled with the background when h is not invertible
+func Warp(img image.Image, h Homography, width, height int, opts Options) image.Image {
+	newImage, _ := WarpContext(context.Background(), img, h, width, height, opts, nil)
+	return newImage
+}
+
+// WarpContext works like Warp but aborts as soon as ctx is cancelled and
+// reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image
+//   - h: The transform from the source to the result coordinates
+//   - width: The width of the result (in pixels)
+//   - height: The height of the result (in pixels)
+//   - opts: The resampling filter and the background colour
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The warped image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func WarpContext(ctx context.Context, img image.Image, h Homography, width, height int, opts Options, progress utils.ProgressFunc) (image.Image, error) {
+	inverse, ok := h.Invert()
+	if !ok {
+		return fill(img, width, height, opts), nil
+	}
+	return remap(ctx, img, width, height, opts, inverse.Apply, progress)
+}
+
+// Perspective straightens the quadrilateral with the given corners into a
+// width x height rectangle, e.g. a photographed document into a flat page.
+//
+// Parameters:
+//   - img: The input image
+//   - corners: The top left, top right, bottom right and bottom left corners of the
+//     area to straighten, relative to the top left corner of img
+//   - width: The width of the result (in pixels)
+//   - height: The height of the result (in pixels)
+//   - opts: The resampling filter and the background colour
+//
+// Returns:
+//   - image.Image: A new RGBA64 image, filled with the background when the corners are degenerate
+//
+// Example:
+//
+//	corners := [4]warp.Point{{X: 120, Y: 80}, {X: 910, Y: 140}, {X: 980, Y: 1260}, {X: 60, Y: 1190}}
+//	page := warp.Perspective(photo, corners, 850, 1100, warp.Options{})
+func Perspective(img image.Image, corners [4]Point, width, height int, opts Options) image.Image {
+	newImage, _ := PerspectiveContext(context.Background(), img, corners, width, height, opts, nil)
+	return newImage
+}
+
+// PerspectiveContext works like Perspective but aborts as soon as ctx is
+// cancelled and reports the number of completed rows to progress.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image
+//   - corners: The top left, top right, bottom right and bottom left corners of the area
+//   - width: The width of the result (in pixels)
+//   - height: The height of the result (in pixels)
+//   - opts: The resampling filter and the background colour
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The straightened image, nil when cancelled
+//   - error: ctx.Err() when the context was cancelled
+func PerspectiveContext(ctx context.Context, img image.Image, corners [4]Point, width, height int, opts Options, progress utils.ProgressFunc) (image.Image, error) {
+	w, h := float64(width), float64(height)
+	homography, err := NewHomography(corners, [4]Point{{0, 0}, {w, 0}, {w, h}, {0, h}})
+	if err != nil {
+		return fill(img, width, height, opts), nil
+	}
+	return WarpContext(ctx, img, homography, width, height, opts, progress)
+}
+
+// PerspectiveStrict works like Perspective but returns an error for
+// degenerate corners, an unknown filter or an empty size.
+//
+// Parameters:
+//   - img: The input image
+//   - corners: The top left, top right, bottom right and bottom left corners of the area
+//   - width: The width of the result (1-65535)
+//   - height: The height of the result (1-65535)
+//   - opts: The resampling filter and the background colour
+//
+// Returns:
+//   - image.Image
+//   - error: ErrDegenerate or a *utils.ValidationError describing the first invalid parameter
+func PerspectiveStrict(img image.Image, corners [4]Point, width, height int, opts Options) (image.Image, error) {
+	return PerspectiveStrictContext(context.Background(), img, corners, width, height, opts, nil)
+}
+
+// PerspectiveStrictContext works like PerspectiveStrict but aborts as soon as
+// ctx is cancelled and reports progress like PerspectiveContext.
+//
+// Parameters:
+//   - ctx: Context controlling the cancellation
+//   - img: The input image
+//   - corners: The top left, top right, bottom right and bottom left corners of the area
+//   - width: The width of the result (1-65535)
+//   - height: The height of the result (1-65535)
+//   - opts: The resampling filter and the background colour
+//   - progress: Optional callback receiving the completed and total rows, may be nil
+//
+// Returns:
+//   - image.Image: The straightened image, nil when cancelled or invalid
+//   - error: ErrDegenerate, a *utils.ValidationError, or ctx.Err() when the context was cancelled
+func PerspectiveStrictContext(ctx context.Context, img image.Image, corners [4]Point, width, height int, opts Options, progress utils.ProgressFunc) (image.Image, error) {
+	if err := utils.ValidateRange("width", width, 1, 65535); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateRange("height", height, 1, 65535); err != nil {
+		return nil, err
+	}
+	if opts.Filter != "" {
+		if err := utils.ValidateOption("filter", opts.Filter, resize.Filters()); err != nil {
+			return nil, err
+		}
+	}
+	w, h := float64(width), float64(height)
+	homography, err := NewHomography(corners, [4]Point{{0, 0}, {w, 0}, {w, h}, {0, h}})
+	if err != nil {
+		return nil, err
+	}
+	return WarpContext(ctx, img, homography, width, height, opts, progress)
+}
@@ -0,0 +1,105 @@
+// Package warp provides the geometric distortions mapping every pixel of the
+// result back to a position of the source image: perspective (homography)
+// warps and lens distortion. The source is sampled with the filters of the
+// resize package.
+package warp
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/BrunoPoiano/imgeffects/resize"
+	"github.com/BrunoPoiano/imgeffects/utils"
+)
+
+// Point is a position in the continuous coordinates of an image, relative to
+// its top left corner: the pixel (i, j) covers [i, i+1) x [j, j+1).
+type Point struct {
+	X, Y float64
+}
+
+// Options configures the warps.
+//
+// Fields:
+//   - Filter: The resampling filter, empty or unknown means resize.Bilinear
+//   - Background: The colour of the areas outside the source image, nil is transparent
+type Options struct {
+	Filter     resize.Filter
+	Background color.Color
+}
+
+// remap fills an image of the given size, starting at the origin of img, with
+// the source colours at the positions returned by source for the centre of
+// every pixel. source receives and returns coordinates relative to the top
+// left corners, and reports false for positions without a source.
+func remap(ctx context.Context, img image.Image, width, height int, opts Options, source func(x, y float64) (float64, float64, bool), progress utils.ProgressFunc) (image.Image, error) {
+	bounds := img.Bounds()
+	newImage := image.NewRGBA64(image.Rectangle{bounds.Min, bounds.Min.Add(image.Point{max(width, 0), max(height, 0)})})
+	if newImage.Rect.Empty() {
+		return newImage, nil
+	}
+
+	filter := opts.Filter
+	if filter == "" {
+		filter = resize.Bilinear
+	}
+	sampler := resize.NewSampler(img, filter)
+
+	var br, bg, bb, ba uint32
+	if opts.Background != nil {
+		br, bg, bb, ba = opts.Background.RGBA()
+	}
+
+	remapFunc := func(start, end int, _ *image.RGBA64, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for y := start; y < end; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for x := 0; x < width; x++ {
+				var c color.RGBA64
+				if sx, sy, ok := source(float64(x)+0.5, float64(y)+0.5); ok {
+					c = sampler.At(sx, sy)
+				}
+
+				// Composite the sample over the background.
+				if ba != 0 {
+					rest := 0xffff - uint32(c.A)
+					c = color.RGBA64{
+						uint16(uint32(c.R) + br*rest/0xffff),
+						uint16(uint32(c.G) + bg*rest/0xffff),
+						uint16(uint32(c.B) + bb*rest/0xffff),
+						uint16(uint32(c.A) + ba*rest/0xffff),
+					}
+				}
+				newImage.SetRGBA64(bounds.Min.X+x, bounds.Min.Y+y, c)
+			}
+		}
+	}
+
+	_, err := utils.ParallelExecutionContext(ctx, utils.ParallelExecutionStruct{
+		Image:    img,
+		Function: remapFunc,
+		EndSize:  height,
+		Progress: progress,
+		NoOutput: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newImage, nil
+}
+
+// fill returns an image of the given size, starting at the origin of img,
+// filled with the background of opts.
+func fill(img image.Image, width, height int, opts Options) image.Image {
+	bounds := img.Bounds()
+	newImage := image.NewRGBA64(image.Rectangle{bounds.Min, bounds.Min.Add(image.Point{max(width, 0), max(height, 0)})})
+	if opts.Background != nil {
+		draw.Draw(newImage, newImage.Rect, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	}
+	return newImage
+}